@@ -2,6 +2,12 @@ package v1
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"syscall"
 	"time"
 
 	celestials "github.com/celenium-io/celestial-module/pkg/api"
@@ -9,6 +15,8 @@ import (
 	fastshot "github.com/opus-domini/fast-shot"
 	"github.com/opus-domini/fast-shot/constant/mime"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
 	"golang.org/x/time/rate"
 )
 
@@ -16,6 +24,11 @@ type Api struct {
 	client      fastshot.ClientHttpMethods
 	timeout     time.Duration
 	rateLimiter *rate.Limiter
+	maxAttempts int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+
+	requestDuration *prometheus.HistogramVec
 }
 
 func New(baseUrl string, opts ...ApiOption) Api {
@@ -23,6 +36,9 @@ func New(baseUrl string, opts ...ApiOption) Api {
 		client:      fastshot.NewClient(baseUrl).Build(),
 		timeout:     time.Second * 10,
 		rateLimiter: rate.NewLimiter(rate.Every(time.Second/time.Duration(5)), 5),
+		maxAttempts: 3,
+		minBackoff:  time.Millisecond * 500,
+		maxBackoff:  time.Second * 10,
 	}
 
 	for i := range opts {
@@ -32,6 +48,17 @@ func New(baseUrl string, opts ...ApiOption) Api {
 	return api
 }
 
+// StatusError - error returned when Celestials API responds with non-successful status code
+type StatusError struct {
+	Code       int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("status=%d text=%s", e.Code, e.Body)
+}
+
 func (api Api) Changes(ctx context.Context, chainId string, opts ...celestials.ChangeOption) (changes celestials.Changes, err error) {
 	var opt = celestials.ChangeOptions{
 		ChainId: chainId,
@@ -40,23 +67,127 @@ func (api Api) Changes(ctx context.Context, chainId string, opts ...celestials.C
 		opts[i](&opt)
 	}
 
+	for attempt := 1; ; attempt++ {
+		changes, err = api.changes(ctx, opt)
+		if err == nil || attempt >= api.maxAttempts || !isTransient(ctx, err) {
+			return
+		}
+
+		delay := api.backoff(attempt, err)
+		log.Warn().
+			Err(err).
+			Str("chain_id", chainId).
+			Int("attempt", attempt).
+			Int("max_attempts", api.maxAttempts).
+			Dur("delay", delay).
+			Msg("retrying celestials request")
+
+		if err := sleep(ctx, delay); err != nil {
+			return changes, err
+		}
+	}
+}
+
+func (api Api) changes(ctx context.Context, opt celestials.ChangeOptions) (changes celestials.Changes, err error) {
+	if err := api.rateLimiter.Wait(ctx); err != nil {
+		return changes, errors.Wrap(err, "rate limiter")
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx, api.timeout)
+	defer cancel()
+
+	start := time.Now()
 	response, err := api.client.POST("api/resolver/changes").
-		Context().Set(ctx).
+		Context().Set(requestCtx).
 		Body().AsJSON(opt).
 		Header().AddContentType(mime.JSON).
 		Send()
 	if err != nil {
+		api.observeRequest(start, 0)
 		return changes, err
 	}
+	api.observeRequest(start, response.Status().Code())
 
 	if response.Status().IsError() {
 		body, err := response.Body().AsString()
 		if err != nil {
 			return changes, err
 		}
-		return changes, errors.Errorf("status=%d text=%s", response.Status().Code(), body)
+		return changes, StatusError{
+			Code:       response.Status().Code(),
+			Body:       body,
+			RetryAfter: parseRetryAfter(response.Header().Get("Retry-After")),
+		}
 	}
+	defer response.Body().Close()
 
 	err = json.NewDecoder(response.Raw().Body).Decode(&changes)
 	return
 }
+
+// backoff - returns delay before next attempt: exponential growth from minBackoff capped by maxBackoff with equal jitter.
+// Retry-After received from server takes precedence but is capped by maxBackoff too.
+func (api Api) backoff(attempt int, err error) time.Duration {
+	var statusErr StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return min(statusErr.RetryAfter, api.maxBackoff)
+	}
+
+	delay := api.maxBackoff
+	if shift := attempt - 1; shift < 32 {
+		if d := api.minBackoff << shift; d > 0 && d < api.maxBackoff {
+			delay = d
+		}
+	}
+	if half := int64(delay / 2); half > 0 {
+		delay = time.Duration(half + rand.Int64N(half+1)) //nolint:gosec
+	}
+	return delay
+}
+
+func isTransient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var statusErr StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code == http.StatusTooManyRequests || statusErr.Code >= http.StatusInternalServerError
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,152 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const testChainId = "celestia"
+
+func TestApiChangesRetry(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			_, _ = w.Write([]byte(`{"head":1,"changes":[{"celestial_id":"test","address":"addr","change_id":1,"status":"PRIMARY"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	api := New(server.URL, WithMaxAttempts(3), WithBackoff(time.Millisecond, time.Millisecond*5), WithMetrics(registry))
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second*5)
+	defer cancel()
+
+	changes, err := api.Changes(ctx, testChainId)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, calls.Load())
+	require.EqualValues(t, 1, changes.Head)
+	require.Len(t, changes.Changes, 1)
+	require.Equal(t, "test", changes.Changes[0].CelestialID)
+
+	require.Equal(t, 3, testutil.CollectAndCount(registry, "celestials_api_request_duration_seconds"))
+}
+
+func TestApiChangesNotRetryClientError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("bad request"))
+	}))
+	defer server.Close()
+
+	api := New(server.URL, WithMaxAttempts(5), WithBackoff(time.Millisecond, time.Millisecond*5))
+
+	_, err := api.Changes(t.Context(), testChainId)
+	require.Error(t, err)
+
+	var statusErr StatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, http.StatusBadRequest, statusErr.Code)
+	require.Equal(t, "bad request", statusErr.Body)
+	require.EqualValues(t, 1, calls.Load())
+}
+
+func TestApiChangesMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	api := New(server.URL, WithMaxAttempts(2), WithBackoff(time.Millisecond, time.Millisecond*5))
+
+	_, err := api.Changes(t.Context(), testChainId)
+	require.Error(t, err)
+	require.EqualValues(t, 2, calls.Load())
+}
+
+func TestApiChangesContextCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	api := New(server.URL, WithMaxAttempts(10))
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Millisecond*200)
+	defer cancel()
+
+	start := time.Now()
+	_, err := api.Changes(ctx, testChainId)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second*5)
+}
+
+func TestApiChangesRetryAfterCapped(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"head":1,"changes":[]}`))
+	}))
+	defer server.Close()
+
+	api := New(server.URL, WithMaxAttempts(2), WithBackoff(time.Millisecond, time.Millisecond*5))
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second*5)
+	defer cancel()
+
+	_, err := api.Changes(ctx, testChainId)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, calls.Load())
+}
+
+func TestApiChangesTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	api := New(server.URL, WithTimeout(time.Millisecond*50), WithMaxAttempts(1))
+
+	start := time.Now()
+	_, err := api.Changes(t.Context(), testChainId)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), time.Millisecond*500)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	require.Equal(t, time.Duration(0), parseRetryAfter(""))
+	require.Equal(t, time.Duration(0), parseRetryAfter("invalid"))
+	require.Equal(t, time.Duration(0), parseRetryAfter("-1"))
+	require.Equal(t, 3*time.Second, parseRetryAfter("3"))
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	delay := parseRetryAfter(date)
+	require.Greater(t, delay, time.Duration(0))
+	require.LessOrEqual(t, delay, time.Minute)
+}
@@ -0,0 +1,43 @@
+package v1
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const errorCode = "error"
+
+func newRequestDuration(reg prometheus.Registerer) *prometheus.HistogramVec {
+	requestDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "celestials",
+		Subsystem: "api",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of requests to Celestials API by response status code",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"code"})
+
+	if err := reg.Register(requestDuration); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if errors.As(err, &alreadyRegistered) {
+			if existing, ok := alreadyRegistered.ExistingCollector.(*prometheus.HistogramVec); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return requestDuration
+}
+
+func (api Api) observeRequest(start time.Time, code int) {
+	if api.requestDuration == nil {
+		return
+	}
+	label := errorCode
+	if code > 0 {
+		label = strconv.Itoa(code)
+	}
+	api.requestDuration.WithLabelValues(label).Observe(time.Since(start).Seconds())
+}
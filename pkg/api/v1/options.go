@@ -3,6 +3,7 @@ package v1
 import (
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/time/rate"
 )
 
@@ -18,3 +19,28 @@ func WithRateLimit(rps int) ApiOption {
 		api.rateLimiter = rate.NewLimiter(rate.Every(time.Second/time.Duration(rps)), rps)
 	}
 }
+
+func WithMaxAttempts(attempts int) ApiOption {
+	return func(api *Api) {
+		if attempts > 0 {
+			api.maxAttempts = attempts
+		}
+	}
+}
+
+func WithBackoff(minBackoff, maxBackoff time.Duration) ApiOption {
+	return func(api *Api) {
+		if minBackoff > 0 {
+			api.minBackoff = minBackoff
+		}
+		api.maxBackoff = max(maxBackoff, api.minBackoff)
+	}
+}
+
+func WithMetrics(reg prometheus.Registerer) ApiOption {
+	return func(api *Api) {
+		if reg != nil {
+			api.requestDuration = newRequestDuration(reg)
+		}
+	}
+}
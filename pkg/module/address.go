@@ -0,0 +1,143 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// BatchAddressHandler - resolves several addresses in one call. Addresses which are absent in the result
+// are resolved by AddressHandler if it's set. To report errors of single addresses without failing the whole batch
+// return AddressErrors with the partial result.
+type BatchAddressHandler func(ctx context.Context, addresses []string) (map[string]uint64, error)
+
+// AddressErrors - errors of single addresses returned by BatchAddressHandler
+type AddressErrors map[string]error
+
+func (e AddressErrors) Error() string {
+	addresses := make([]string, 0, len(e))
+	for address, err := range e {
+		addresses = append(addresses, fmt.Sprintf("%s: %s", address, err))
+	}
+	slices.Sort(addresses)
+	return strings.Join(addresses, "; ")
+}
+
+var errAddressNotResolved = errors.New("address was not resolved by batch handler")
+
+// batchHandler - returns batch address handler of network. Module's batch handler is used only for networks
+// which do not have own address handler.
+func (m *Module) batchHandler(n *networkSync) BatchAddressHandler {
+	if n.addressHandler != nil {
+		return nil
+	}
+	return m.batchAddressHandler
+}
+
+// resolveAddress - resolves single address by address handler or by batch address handler if the former is not set
+func (m *Module) resolveAddress(ctx context.Context, n *networkSync, address string) (uint64, error) {
+	if handler := m.handler(n); handler != nil {
+		return handler(ctx, address)
+	}
+
+	changes := []pendingChange{{}}
+	changes[0].change.Address = address
+	m.resolveAddresses(ctx, n, changes)
+	return changes[0].addressId, changes[0].err
+}
+
+// resolveAddresses - resolves addresses of changes by batch address handler and the rest of them by pool of
+// address workers. Results are stored to the passed changes.
+func (m *Module) resolveAddresses(ctx context.Context, n *networkSync, changes []pendingChange) {
+	var indices []int
+	if batch := m.batchHandler(n); batch != nil {
+		indices = m.resolveBatch(ctx, n, batch, changes)
+	} else {
+		indices = make([]int, len(changes))
+		for i := range indices {
+			indices[i] = i
+		}
+	}
+
+	if handler := m.handler(n); handler != nil && len(indices) > 0 {
+		m.resolveSingle(ctx, handler, changes, indices)
+	}
+}
+
+// resolveBatch - resolves unique addresses of changes in one call. Changes which received own error from
+// the handler are failed. It returns indices of changes which were not resolved by the batch handler.
+func (m *Module) resolveBatch(ctx context.Context, n *networkSync, batch BatchAddressHandler, changes []pendingChange) []int {
+	addresses := make([]string, 0, len(changes))
+	seen := make(map[string]struct{}, len(changes))
+	for i := range changes {
+		if _, ok := seen[changes[i].change.Address]; ok {
+			continue
+		}
+		seen[changes[i].change.Address] = struct{}{}
+		addresses = append(addresses, changes[i].change.Address)
+	}
+
+	result, err := batch(ctx, addresses)
+
+	var addressErrors AddressErrors
+	if err != nil && !errors.As(err, &addressErrors) {
+		m.Log.Err(err).
+			Str("network", n.name).
+			Int("addresses_count", len(addresses)).
+			Msg("batch address handler")
+		err = errors.Wrap(err, "batch address handler")
+		result = nil
+	} else {
+		err = errAddressNotResolved
+	}
+
+	unresolved := make([]int, 0)
+	for i := range changes {
+		address := changes[i].change.Address
+		if addressErr, ok := addressErrors[address]; ok {
+			changes[i].err = addressErr
+			continue
+		}
+		if id, ok := result[address]; ok {
+			changes[i].addressId = id
+			continue
+		}
+		changes[i].err = err
+		unresolved = append(unresolved, i)
+	}
+	return unresolved
+}
+
+// resolveSingle - resolves addresses of changes with passed indices by pool of address workers
+func (m *Module) resolveSingle(ctx context.Context, handler AddressHandler, changes []pendingChange, indices []int) {
+	resolve := func(i int) {
+		changes[i].addressId, changes[i].err = handler(ctx, changes[i].change.Address)
+	}
+
+	workers := min(m.addressWorkers, len(indices))
+	if workers <= 1 {
+		for _, i := range indices {
+			resolve(i)
+		}
+		return
+	}
+
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for i := range queue {
+				resolve(i)
+			}
+		})
+	}
+	for _, i := range indices {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+}
@@ -0,0 +1,367 @@
+package module
+
+import (
+	"context"
+	"hash/fnv"
+	"slices"
+	"strings"
+
+	celestials "github.com/celenium-io/celestial-module/pkg/api"
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/celenium-io/celestial-module/pkg/storage/postgres"
+	"github.com/pkg/errors"
+)
+
+// auditPageSize - count of local celestial ids received by one query during audit
+const auditPageSize = 1000
+
+// AuditMismatch - celestial id which differs from the state expected by the change stream of Celestials API.
+// Expected is nil for extra names, Actual is nil for missing ones.
+type AuditMismatch struct {
+	Id       string             `json:"id"`
+	Expected *storage.Celestial `json:"expected,omitempty"`
+	Actual   *storage.Celestial `json:"actual,omitempty"`
+}
+
+// AuditReport - result of comparison of local celestial ids with the change stream of Celestials API
+type AuditReport struct {
+	Network string `json:"network"`
+	// ChangeId - changes up to this id are audited. Local rows changed later are not compared.
+	ChangeId int64 `json:"change_id"`
+	Checked  int   `json:"checked"`
+	// Skipped - count of names which can't be compared because of unknown status or address handler error
+	Skipped  int             `json:"skipped"`
+	Missing  []AuditMismatch `json:"missing,omitempty"`
+	Stale    []AuditMismatch `json:"stale,omitempty"`
+	Extra    []AuditMismatch `json:"extra,omitempty"`
+	Repaired int             `json:"repaired"`
+}
+
+// Consistent - returns true if no mismatches were found
+func (r AuditReport) Consistent() bool {
+	return len(r.Missing) == 0 && len(r.Stale) == 0 && len(r.Extra) == 0
+}
+
+type auditConfig struct {
+	sample float64
+	repair bool
+}
+
+// AuditOption - option of Module.Audit
+type AuditOption func(*auditConfig)
+
+// WithAuditSample - audits only the passed share of names (0 < rate < 1). Names are sampled by hash of celestial id,
+// so the same names are audited every time. The whole change stream is received anyway.
+func WithAuditSample(rate float64) AuditOption {
+	return func(cfg *auditConfig) {
+		cfg.sample = rate
+	}
+}
+
+// WithAuditRepair - saves expected state of missing and stale names by sync goroutine of the network without writing
+// history. Extra names are only reported. Module must be started and be a leader.
+func WithAuditRepair() AuditOption {
+	return func(cfg *auditConfig) {
+		cfg.repair = true
+	}
+}
+
+// expectedCelestial - state of celestial id computed from the change stream before address resolution
+type expectedCelestial struct {
+	change celestials.Change
+	status storage.Status
+	known  bool
+}
+
+// Audit - receives the whole change stream of network up to the saved state, computes the expected final state
+// of every name and compares it with local celestial ids.
+func (m *Module) Audit(ctx context.Context, network string, opts ...AuditOption) (AuditReport, error) {
+	var cfg auditConfig
+	for i := range opts {
+		opts[i](&cfg)
+	}
+
+	n, err := m.network(network)
+	if err != nil {
+		return AuditReport{}, err
+	}
+	if cfg.repair && !m.IsLeader() {
+		return AuditReport{}, errors.New("audit with repair can be executed only by leader")
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx, m.databaseTimeout)
+	state, err := m.states.ByName(requestCtx, m.indexerName, n.name)
+	cancel()
+	if err != nil {
+		return AuditReport{}, errors.Wrap(err, "state by name")
+	}
+
+	report := AuditReport{
+		Network:  n.name,
+		ChangeId: state.ChangeId,
+	}
+
+	expected, err := m.expectedCelestials(ctx, n, state.ChangeId, cfg.sample)
+	if err != nil {
+		return report, err
+	}
+	resolved := m.resolveExpected(ctx, n, expected, &report)
+
+	if err := m.compareCelestials(ctx, n, resolved, cfg.sample, &report); err != nil {
+		return report, err
+	}
+
+	if cfg.repair {
+		if err := m.repair(ctx, n, &report); err != nil {
+			return report, errors.Wrap(err, "repair")
+		}
+	}
+
+	m.Log.Info().
+		Str("network", n.name).
+		Int64("change_id", report.ChangeId).
+		Int("checked", report.Checked).
+		Int("skipped", report.Skipped).
+		Int("missing", len(report.Missing)).
+		Int("stale", len(report.Stale)).
+		Int("extra", len(report.Extra)).
+		Int("repaired", report.Repaired).
+		Msg("audit is finished")
+	return report, nil
+}
+
+// sampled - returns true if name is audited with passed sample rate
+func sampled(id string, rate float64) bool {
+	if rate <= 0 || rate >= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return float64(h.Sum32()%1_000_000) < rate*1_000_000
+}
+
+// expectedCelestials - replays changes up to passed change id and returns sampled names. Primary status is revoked
+// when other name becomes primary for the same address, the same as UpdateStatusForAddress does.
+func (m *Module) expectedCelestials(ctx context.Context, n *networkSync, toChangeId int64, sample float64) (map[string]*expectedCelestial, error) {
+	expected := make(map[string]*expectedCelestial)
+	primary := make(map[string]string)
+
+	var fromChangeId int64
+	for fromChangeId < toChangeId {
+		changes, err := m.getChanges(ctx, n, fromChangeId)
+		if err != nil {
+			return nil, errors.Wrap(err, "get changes")
+		}
+
+		for _, change := range changes.Changes {
+			if change.ChangeID <= fromChangeId || change.ChangeID > toChangeId {
+				continue
+			}
+			fromChangeId = change.ChangeID
+
+			if prev, ok := expected[change.CelestialID]; ok && prev.status == storage.StatusPRIMARY && primary[prev.change.Address] == change.CelestialID {
+				delete(primary, prev.change.Address)
+			}
+
+			status, err := storage.ParseStatus(change.Status)
+			if err == nil && status == storage.StatusPRIMARY {
+				if other, ok := primary[change.Address]; ok {
+					expected[other].status = storage.StatusVERIFIED
+				}
+				primary[change.Address] = change.CelestialID
+			}
+
+			expected[change.CelestialID] = &expectedCelestial{
+				change: change,
+				status: status,
+				known:  err == nil,
+			}
+		}
+
+		if len(changes.Changes) < int(m.limit) {
+			break
+		}
+	}
+
+	for id := range expected {
+		if !sampled(id, sample) {
+			delete(expected, id)
+		}
+	}
+	return expected, nil
+}
+
+// resolveExpected - resolves addresses of expected names. Names with unknown status or unresolved address are skipped.
+func (m *Module) resolveExpected(ctx context.Context, n *networkSync, expected map[string]*expectedCelestial, report *AuditReport) map[string]*storage.Celestial {
+	pending := make([]pendingChange, 0, len(expected))
+	for _, e := range expected {
+		pending = append(pending, pendingChange{
+			change:      e.change,
+			status:      e.status,
+			quarantined: !e.known,
+		})
+	}
+	m.resolveAddresses(ctx, n, pending)
+
+	resolved := make(map[string]*storage.Celestial, len(pending))
+	for _, pc := range pending {
+		if pc.quarantined || pc.err != nil {
+			report.Skipped++
+			resolved[pc.change.CelestialID] = nil
+			continue
+		}
+		resolved[pc.change.CelestialID] = &storage.Celestial{
+			Id:        pc.change.CelestialID,
+			Network:   n.name,
+			AddressId: pc.addressId,
+			ImageUrl:  pc.change.ImageURL,
+			ChangeId:  pc.change.ChangeID,
+			Status:    pc.status,
+		}
+	}
+	return resolved
+}
+
+// compareCelestials - compares local celestial ids with expected ones. Local rows changed after the audited change
+// are not compared. Skipped names are present in expected map with nil value.
+func (m *Module) compareCelestials(ctx context.Context, n *networkSync, expected map[string]*storage.Celestial, sample float64, report *AuditReport) error {
+	seen := make(map[string]struct{}, len(expected))
+
+	var afterId string
+	for {
+		local, err := m.celestialsAfter(ctx, n, afterId)
+		if err != nil {
+			return errors.Wrap(err, "receive celestials")
+		}
+
+		for i := range local {
+			actual := local[i]
+			seen[actual.Id] = struct{}{}
+
+			if !sampled(actual.Id, sample) || actual.ChangeId > report.ChangeId {
+				continue
+			}
+
+			want, ok := expected[actual.Id]
+			switch {
+			case !ok:
+				report.Extra = append(report.Extra, AuditMismatch{Id: actual.Id, Actual: &actual})
+			case want == nil:
+				continue
+			case want.AddressId != actual.AddressId || want.ImageUrl != actual.ImageUrl ||
+				want.ChangeId != actual.ChangeId || want.Status != actual.Status:
+				report.Stale = append(report.Stale, AuditMismatch{Id: actual.Id, Expected: want, Actual: &actual})
+			}
+			report.Checked++
+		}
+
+		if len(local) < auditPageSize {
+			break
+		}
+		afterId = local[len(local)-1].Id
+	}
+
+	for id, want := range expected {
+		if _, ok := seen[id]; ok || want == nil {
+			continue
+		}
+		report.Missing = append(report.Missing, AuditMismatch{Id: id, Expected: want})
+		report.Checked++
+	}
+	slices.SortFunc(report.Missing, func(a, b AuditMismatch) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+	return nil
+}
+
+func (m *Module) celestialsAfter(ctx context.Context, n *networkSync, afterId string) ([]storage.Celestial, error) {
+	requestCtx, cancel := context.WithTimeout(ctx, m.databaseTimeout)
+	defer cancel()
+
+	tx, err := postgres.BeginCelestialTransaction(requestCtx, m.tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin transactions")
+	}
+	defer tx.Close(requestCtx)
+
+	result, err := tx.CelestialsAfter(requestCtx, n.name, afterId, auditPageSize)
+	if err != nil {
+		return nil, tx.HandleError(requestCtx, err)
+	}
+	return result, tx.Rollback(requestCtx)
+}
+
+type repairRequest struct {
+	celestials []storage.Celestial
+	result     chan error
+}
+
+// repair - saves expected state of missing and stale names. Repair is executed by sync goroutine of the network,
+// so module must be started. Rows which were changed concurrently are not overwritten because SaveCelestials
+// does not replace newer changes.
+func (m *Module) repair(ctx context.Context, n *networkSync, report *AuditReport) error {
+	req := repairRequest{
+		celestials: make([]storage.Celestial, 0, len(report.Missing)+len(report.Stale)),
+		result:     make(chan error, 1),
+	}
+	for _, mismatch := range report.Missing {
+		req.celestials = append(req.celestials, *mismatch.Expected)
+	}
+	for _, mismatch := range report.Stale {
+		req.celestials = append(req.celestials, *mismatch.Expected)
+	}
+	if len(req.celestials) == 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case n.repair <- req:
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-req.result:
+		if err != nil {
+			return err
+		}
+	}
+	report.Repaired = len(req.celestials)
+	return nil
+}
+
+// applyRepair - saves repaired celestial ids. History is not written because changes of repaired names
+// were already recorded when they were applied first time. Repair is rejected while resync is running
+// because the live table is replaced by the swap.
+func (m *Module) applyRepair(ctx context.Context, n *networkSync, cids []storage.Celestial) error {
+	if n.resync.Load() != nil {
+		return errors.Errorf("resync of %s is running", n.name)
+	}
+
+	b := newBatch(n.name)
+	b.skipHistory = true
+	for i := range cids {
+		b.apply(cids[i])
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx, m.databaseTimeout)
+	defer cancel()
+
+	tx, err := postgres.BeginCelestialTransaction(requestCtx, m.tx)
+	if err != nil {
+		return errors.Wrap(err, "begin transactions")
+	}
+	defer tx.Close(requestCtx)
+
+	if err := m.saveBatch(requestCtx, tx, b); err != nil {
+		return tx.HandleError(requestCtx, err)
+	}
+	if err := tx.Flush(requestCtx); err != nil {
+		return errors.Wrap(err, "flush")
+	}
+	m.invalidate(b)
+	m.publish(ctx, b)
+	return nil
+}
@@ -0,0 +1,105 @@
+package module
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/celenium-io/celestial-module/pkg/storage/postgres"
+	"github.com/pkg/errors"
+)
+
+// batch - set of changes which are saved in one transaction
+type batch struct {
+	network    string
+	celestials map[string]storage.Celestial
+	// primaries - celestial id which becomes primary for every address in batch
+	primaries map[uint64]string
+	// revoked - celestial ids out of batch which lost primary status because other celestial id became primary for their address
+	revoked   []storage.Celestial
+	failed    []storage.CelestialFailedChange
+	history   []storage.CelestialHistory
+	messages  []ChangeMessage
+	indexedAt time.Time
+	// skipHistory - history rows are not saved because changes of batch were already recorded
+	skipHistory bool
+}
+
+func newBatch(network string) *batch {
+	return &batch{
+		network:    network,
+		celestials: make(map[string]storage.Celestial),
+		primaries:  make(map[uint64]string),
+		failed:     make([]storage.CelestialFailedChange, 0),
+		history:    make([]storage.CelestialHistory, 0),
+		indexedAt:  time.Now().UTC(),
+	}
+}
+
+func (b *batch) apply(cid storage.Celestial) {
+	cid.Network = b.network
+	if cid.Status == storage.StatusPRIMARY {
+		cid.Status = b.resolvePrimary(cid)
+	}
+	b.celestials[cid.Id] = cid
+	b.history = append(b.history, storage.CelestialHistory{
+		IndexedAt:   b.indexedAt,
+		ChangeId:    cid.ChangeId,
+		Network:     b.network,
+		CelestialId: cid.Id,
+		AddressId:   cid.AddressId,
+		ImageUrl:    cid.ImageUrl,
+		Status:      cid.Status,
+	})
+}
+
+// resolvePrimary - keeps one primary celestial id per address in batch. The one with the greatest change id wins,
+// so the result does not depend on the order of applying.
+func (b *batch) resolvePrimary(cid storage.Celestial) storage.Status {
+	if id, ok := b.primaries[cid.AddressId]; ok && id != cid.Id {
+		other := b.celestials[id]
+		if other.AddressId == cid.AddressId && other.Status == storage.StatusPRIMARY {
+			if other.ChangeId > cid.ChangeId {
+				return storage.StatusVERIFIED
+			}
+			other.Status = storage.StatusVERIFIED
+			b.celestials[id] = other
+		}
+	}
+	b.primaries[cid.AddressId] = cid.Id
+	return storage.StatusPRIMARY
+}
+
+func (b *batch) fail(change storage.CelestialFailedChange) {
+	change.Network = b.network
+	b.failed = append(b.failed, change)
+}
+
+func (m *Module) saveBatch(ctx context.Context, tx postgres.CelestialTransaction, b *batch) error {
+	if err := b.buildMessages(ctx, tx); err != nil {
+		return errors.Wrap(err, "build messages")
+	}
+
+	revoked, err := tx.UpdateStatusForAddress(ctx, b.network, b.primaries)
+	if err != nil {
+		return errors.Wrap(err, "update primary statuses")
+	}
+	b.revoke(revoked)
+
+	if err := tx.SaveCelestials(ctx, maps.Values(b.celestials)); err != nil {
+		return errors.Wrap(err, "save celestials")
+	}
+
+	if !b.skipHistory {
+		if err := tx.SaveHistory(ctx, b.history...); err != nil {
+			return errors.Wrap(err, "save history")
+		}
+	}
+
+	if err := tx.SaveFailedChanges(ctx, b.failed...); err != nil {
+		return errors.Wrap(err, "save failed changes")
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package module
+
+import (
+	"maps"
+	"slices"
+)
+
+// CacheInvalidator - cache of celestial ids which is invalidated by module after changes are committed
+type CacheInvalidator interface {
+	// Invalidate - removes celestial ids and primary celestial ids of addresses
+	Invalidate(network string, ids []string, addressIds []uint64)
+	// InvalidateNetwork - removes all entries of network
+	InvalidateNetwork(network string)
+}
+
+// invalidate - removes celestial ids of batch and celestial ids which lost primary status from cache.
+// Previous addresses of changed celestial ids are removed too because they could lose primary celestial id.
+func (m *Module) invalidate(b *batch) {
+	if m.cache == nil || len(b.celestials) == 0 {
+		return
+	}
+
+	ids := slices.Collect(maps.Keys(b.celestials))
+	addressIds := make(map[uint64]struct{}, len(b.celestials))
+	for _, cid := range b.celestials {
+		addressIds[cid.AddressId] = struct{}{}
+	}
+	for _, cid := range b.revoked {
+		ids = append(ids, cid.Id)
+		addressIds[cid.AddressId] = struct{}{}
+	}
+	for i := range b.messages {
+		if !b.messages[i].IsNew {
+			addressIds[b.messages[i].PrevAddressId] = struct{}{}
+		}
+	}
+
+	m.cache.Invalidate(b.network, ids, slices.Collect(maps.Keys(addressIds)))
+}
+
+func (m *Module) invalidateNetwork(network string) {
+	if m.cache != nil {
+		m.cache.InvalidateNetwork(network)
+	}
+}
@@ -0,0 +1,145 @@
+package module
+
+import (
+	"context"
+	stdErrors "errors"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrPaused - returned by SyncNow and WaitSync while module is paused and to waiters of sync when module is paused
+	ErrPaused = errors.New("sync is paused")
+	// ErrNotLeader - returned by SyncNow and WaitSync if module is not a leader and to waiters of sync when leadership is lost
+	ErrNotLeader = errors.New("module is not a leader")
+)
+
+// Trigger - requests immediate sync of all networks. Requests received while sync is running are coalesced
+// into one sync which starts after the current one. Requests are ignored while module is paused.
+func (m *Module) Trigger() {
+	for _, n := range m.networks {
+		select {
+		case n.trigger <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Pause - stops periodic and triggered syncs and retries of failed changes. The running sync is completed.
+// Waiters of syncs which have not started yet receive ErrPaused.
+func (m *Module) Pause() {
+	m.controlMx.Lock()
+	defer m.controlMx.Unlock()
+
+	if m.paused.CompareAndSwap(false, true) {
+		m.Log.Info().Msg("sync is paused")
+		m.cancelWaiters(ErrPaused)
+	}
+}
+
+// Resume - resumes syncing after Pause and triggers immediate sync
+func (m *Module) Resume() {
+	m.controlMx.Lock()
+	if m.paused.CompareAndSwap(true, false) {
+		m.Log.Info().Msg("sync is resumed")
+	}
+	m.controlMx.Unlock()
+
+	m.Trigger()
+}
+
+// Paused - returns true if sync is paused
+func (m *Module) Paused() bool {
+	return m.paused.Load()
+}
+
+// WaitSync - waits for completion of the next sync of every network which starts after the call
+// and returns errors of these syncs. It returns ErrNotLeader immediately if module is not a leader and ErrPaused
+// if module is paused because sync would not start. If module is paused or loses leadership while waiting,
+// the same errors are returned.
+func (m *Module) WaitSync(ctx context.Context) error {
+	waiters, err := m.addWaiters()
+	if err != nil {
+		return err
+	}
+	return m.wait(ctx, waiters)
+}
+
+// SyncNow - triggers immediate sync and waits for its result. It fails the same way as WaitSync
+// if module is not a leader or is paused.
+func (m *Module) SyncNow(ctx context.Context) error {
+	waiters, err := m.addWaiters()
+	if err != nil {
+		return err
+	}
+	m.Trigger()
+	return m.wait(ctx, waiters)
+}
+
+// addWaiters - registers waiters of the next sync of every network. Pause and leadership states are checked
+// under the same lock which is held by Pause and resign, so registered waiters are always notified.
+func (m *Module) addWaiters() ([]chan error, error) {
+	m.controlMx.Lock()
+	defer m.controlMx.Unlock()
+
+	if !m.IsLeader() {
+		return nil, ErrNotLeader
+	}
+	if m.Paused() {
+		return nil, ErrPaused
+	}
+
+	waiters := make([]chan error, len(m.networks))
+	for i := range m.networks {
+		waiters[i] = m.networks[i].addWaiter()
+	}
+	return waiters, nil
+}
+
+// cancelWaiters - notifies waiters of syncs which have not started yet with the error. It should be called under controlMx.
+func (m *Module) cancelWaiters(err error) {
+	for _, n := range m.networks {
+		n.notify(n.takeWaiters(), err)
+	}
+}
+
+func (m *Module) wait(ctx context.Context, waiters []chan error) error {
+	var result error
+	for i := range waiters {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-waiters[i]:
+			if err != nil {
+				result = stdErrors.Join(result, errors.Wrap(err, m.networks[i].name))
+			}
+		}
+	}
+	return result
+}
+
+func (n *networkSync) addWaiter() chan error {
+	waiter := make(chan error, 1)
+
+	n.waitersMx.Lock()
+	n.waiters = append(n.waiters, waiter)
+	n.waitersMx.Unlock()
+
+	return waiter
+}
+
+// takeWaiters - returns waiters of the sync which is starting and clears the list
+func (n *networkSync) takeWaiters() []chan error {
+	n.waitersMx.Lock()
+	defer n.waitersMx.Unlock()
+
+	waiters := n.waiters
+	n.waiters = nil
+	return waiters
+}
+
+func (n *networkSync) notify(waiters []chan error, err error) {
+	for i := range waiters {
+		waiters[i] <- err
+	}
+}
@@ -0,0 +1,153 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	celestials "github.com/celenium-io/celestial-module/pkg/api"
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/celenium-io/celestial-module/pkg/storage/postgres"
+	"github.com/pkg/errors"
+)
+
+const maxRetryDelay = time.Hour
+
+func (m *Module) newFailedChange(network string, change celestials.Change, err error) storage.CelestialFailedChange {
+	return storage.CelestialFailedChange{
+		ChangeId:    change.ChangeID,
+		Network:     network,
+		CelestialId: change.CelestialID,
+		Address:     change.Address,
+		ImageUrl:    change.ImageURL,
+		Status:      change.Status,
+		Error:       err.Error(),
+		Attempts:    1,
+		NextRetryAt: m.nextRetryAt(1),
+	}
+}
+
+// nextRetryAt - returns time of the next attempt. Delay is doubled after each attempt and capped by maxRetryDelay.
+func (m *Module) nextRetryAt(attempts int) time.Time {
+	delay := m.retryPeriod
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return time.Now().UTC().Add(min(delay, maxRetryDelay))
+}
+
+// retryFailedChanges - re-resolves addresses of failed changes and applies them in change id order.
+// Changes which are older than already saved data for the same celestial id are dropped.
+func (m *Module) retryFailedChanges(ctx context.Context, n *networkSync) error {
+	requestCtx, cancel := context.WithTimeout(ctx, m.databaseTimeout)
+	defer cancel()
+
+	tx, err := postgres.BeginCelestialTransaction(requestCtx, m.tx)
+	if err != nil {
+		return errors.Wrap(err, "begin transactions")
+	}
+	defer tx.Close(requestCtx)
+
+	failed, err := tx.FailedChanges(requestCtx, n.name, int(m.limit))
+	if err != nil {
+		return tx.HandleError(requestCtx, errors.Wrap(err, "receive failed changes"))
+	}
+	if len(failed) == 0 {
+		return tx.Rollback(requestCtx)
+	}
+
+	b := newBatch(n.name)
+	processed := make([]int64, 0, len(failed))
+
+	for i := range failed {
+		cid, err := m.resolveFailedChange(requestCtx, n, failed[i])
+		if err != nil {
+			m.Log.Err(err).
+				Str("network", n.name).
+				Str("celestial_id", failed[i].CelestialId).
+				Int64("change_id", failed[i].ChangeId).
+				Int("attempts", failed[i].Attempts).
+				Msg("retry failed change")
+
+			failed[i].Attempts++
+			failed[i].Error = err.Error()
+			failed[i].NextRetryAt = m.nextRetryAt(failed[i].Attempts)
+			b.fail(failed[i])
+			continue
+		}
+
+		processed = append(processed, failed[i].ChangeId)
+		if cid != nil {
+			b.apply(*cid)
+		}
+	}
+
+	if err := m.saveBatch(requestCtx, tx, b); err != nil {
+		return tx.HandleError(requestCtx, err)
+	}
+
+	if err := tx.DeleteFailedChanges(requestCtx, n.name, processed...); err != nil {
+		return tx.HandleError(requestCtx, errors.Wrap(err, "delete failed changes"))
+	}
+
+	if err := tx.Flush(requestCtx); err != nil {
+		return errors.Wrap(err, "flush")
+	}
+	m.invalidate(b)
+	m.publish(ctx, b)
+	m.metrics.changes(n.name, 0, len(b.history))
+
+	m.Log.Info().
+		Str("network", n.name).
+		Int("applied_count", len(b.celestials)).
+		Int("processed_count", len(processed)).
+		Int("failed_count", len(b.failed)).
+		Msg("retried failed changes")
+	return nil
+}
+
+// resolveFailedChange - returns celestial which should be saved for the failed change.
+// It returns nil if the change was superseded by newer data.
+func (m *Module) resolveFailedChange(ctx context.Context, n *networkSync, change storage.CelestialFailedChange) (*storage.Celestial, error) {
+	status, err := storage.ParseStatus(change.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	addressId, err := m.resolveAddress(ctx, n, change.Address)
+	if err != nil {
+		m.metrics.addressFailure(n.name)
+		return nil, errors.Wrap(err, "address handler")
+	}
+
+	current, err := m.celestials.ById(ctx, n.name, change.CelestialId)
+	switch {
+	case err == nil:
+		if current.ChangeId > change.ChangeId {
+			return nil, nil
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, errors.Wrap(err, "celestial by id")
+	}
+
+	if status == storage.StatusPRIMARY {
+		primary, err := m.celestials.Primary(ctx, n.name, addressId)
+		switch {
+		case err == nil:
+			if primary.Id != change.CelestialId && primary.ChangeId > change.ChangeId {
+				status = storage.StatusVERIFIED
+			}
+		case !errors.Is(err, sql.ErrNoRows):
+			return nil, errors.Wrap(err, "primary celestial")
+		}
+	}
+
+	return &storage.Celestial{
+		Id:        change.CelestialId,
+		Network:   n.name,
+		ImageUrl:  change.ImageUrl,
+		AddressId: addressId,
+		ChangeId:  change.ChangeId,
+		Status:    status,
+	}, nil
+}
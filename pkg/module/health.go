@@ -0,0 +1,240 @@
+package module
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	celestials "github.com/celenium-io/celestial-module/pkg/api"
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+)
+
+type HealthStatus string
+
+const (
+	HealthStatusHealthy HealthStatus = "healthy"
+	HealthStatusLagging HealthStatus = "lagging"
+	HealthStatusStalled HealthStatus = "stalled"
+	// HealthStatusStandby - leader election is enabled and the lock is held by another instance
+	HealthStatusStandby HealthStatus = "standby"
+	// HealthStatusPaused - sync is paused by Module.Pause
+	HealthStatusPaused HealthStatus = "paused"
+)
+
+// Health - sync state of module. If module indexes several networks, top-level fields describe the network
+// in the worst state and Networks contains state of every network.
+type Health struct {
+	Network             string          `json:"network,omitempty"`
+	Status              HealthStatus    `json:"status"`
+	LastSyncTime        time.Time       `json:"last_sync_time"`
+	LastError           string          `json:"last_error,omitempty"`
+	ConsecutiveFailures int             `json:"consecutive_failures"`
+	ChangeId            int64           `json:"change_id"`
+	Head                int64           `json:"head"`
+	Lag                 int64           `json:"lag"`
+	Resync              *ResyncProgress `json:"resync,omitempty"`
+	Networks            []Health        `json:"networks,omitempty"`
+}
+
+func (status HealthStatus) severity() int {
+	switch status {
+	case HealthStatusStalled:
+		return 2
+	case HealthStatusLagging:
+		return 1
+	default:
+		return 0
+	}
+}
+
+type healthState struct {
+	mx sync.RWMutex
+
+	startTime           time.Time
+	lastSyncTime        time.Time
+	lastError           error
+	consecutiveFailures int
+	changeId            int64
+	head                int64
+}
+
+func (h *healthState) setHead(head int64) {
+	h.mx.Lock()
+	h.head = head
+	h.mx.Unlock()
+}
+
+func (h *healthState) setChangeId(changeId int64) {
+	h.mx.Lock()
+	h.changeId = changeId
+	h.mx.Unlock()
+}
+
+func (h *healthState) lag() int64 {
+	h.mx.RLock()
+	defer h.mx.RUnlock()
+	return max(h.head-h.changeId, 0)
+}
+
+func (h *healthState) setSyncResult(err error) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	h.lastError = err
+	if err != nil {
+		h.consecutiveFailures++
+		return
+	}
+	h.consecutiveFailures = 0
+	h.lastSyncTime = time.Now().UTC()
+}
+
+// Health - returns current sync state. Network is stalled if there was no successful sync during stall timeout
+// and lagging if difference between API head and applied change id is greater than max lag. Status of standby
+// and paused module overrides statuses of networks while the rest of report is kept.
+func (m *Module) Health() Health {
+	result := m.syncHealth()
+	if override, ok := m.controlStatus(); ok {
+		result.Status = override
+		for i := range result.Networks {
+			result.Networks[i].Status = override
+		}
+	}
+	return result
+}
+
+// controlStatus - returns status set by leader election or pause
+func (m *Module) controlStatus() (HealthStatus, bool) {
+	switch {
+	case !m.IsLeader():
+		return HealthStatusStandby, true
+	case m.Paused():
+		return HealthStatusPaused, true
+	default:
+		return "", false
+	}
+}
+
+func (m *Module) syncHealth() Health {
+	if len(m.networks) == 1 {
+		return m.networkHealth(m.networks[0])
+	}
+
+	var result Health
+	networks := make([]Health, len(m.networks))
+	for i := range m.networks {
+		networks[i] = m.networkHealth(m.networks[i])
+		if i == 0 || networks[i].Status.severity() > result.Status.severity() {
+			result = networks[i]
+		}
+	}
+	result.Networks = networks
+	return result
+}
+
+func (m *Module) networkHealth(n *networkSync) Health {
+	n.health.mx.RLock()
+	defer n.health.mx.RUnlock()
+
+	result := Health{
+		Network:             n.name,
+		Status:              HealthStatusHealthy,
+		LastSyncTime:        n.health.lastSyncTime,
+		ConsecutiveFailures: n.health.consecutiveFailures,
+		ChangeId:            n.health.changeId,
+		Head:                n.health.head,
+		Lag:                 max(n.health.head-n.health.changeId, 0),
+	}
+	if n.health.lastError != nil {
+		result.LastError = n.health.lastError.Error()
+	}
+	if r := n.resync.Load(); r != nil {
+		progress := r.progress()
+		result.Resync = &progress
+	}
+
+	lastSuccess := n.health.lastSyncTime
+	if lastSuccess.IsZero() {
+		lastSuccess = n.health.startTime
+	}
+
+	switch {
+	case !lastSuccess.IsZero() && time.Since(lastSuccess) > m.stallTimeout():
+		result.Status = HealthStatusStalled
+	case result.Lag > m.maxLag:
+		result.Status = HealthStatusLagging
+	}
+	return result
+}
+
+// HealthHandler - returns http handler which responds with JSON-encoded Health. Status code is 503 if module is stalled.
+func (m *Module) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health := m.Health()
+
+		w.Header().Set("Content-Type", "application/json")
+		if health.Status == HealthStatusStalled {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		if err := json.NewEncoder(w).Encode(health); err != nil {
+			m.Log.Err(err).Msg("encode health")
+		}
+	})
+}
+
+func (m *Module) stallTimeout() time.Duration {
+	if m.healthStallTimeout > 0 {
+		return m.healthStallTimeout
+	}
+	if m.maxIndexPeriod > 0 {
+		return m.maxIndexPeriod * 3
+	}
+	return m.indexPeriod * 3
+}
+
+func (m *Module) runSync(ctx context.Context, n *networkSync) error {
+	waiters := n.takeWaiters()
+	err := m.sync(ctx, n)
+	n.health.setSyncResult(err)
+	n.notify(waiters, err)
+	if err == nil {
+		if r := n.resync.Load(); r != nil {
+			if err := m.resyncStep(ctx, n, r); err != nil {
+				m.Log.Err(err).Str("network", n.name).Msg("resync")
+			}
+		}
+		return nil
+	}
+	m.Log.Err(err).Str("network", n.name).Msg("sync")
+
+	if errors.Is(err, storage.ErrStaleState) {
+		// state was moved forward by another instance
+		if err := m.getState(ctx, n); err != nil {
+			m.Log.Err(err).Str("network", n.name).Msg("state receiving")
+		}
+	}
+
+	if err := m.refreshHead(ctx, n); err != nil {
+		m.Log.Debug().Err(err).Str("network", n.name).Msg("receiving head")
+	}
+	return err
+}
+
+// refreshHead - receives only head from API to keep lag actual when sync fails
+func (m *Module) refreshHead(ctx context.Context, n *networkSync) error {
+	requestCtx, cancel := context.WithTimeout(ctx, m.requestTimeout)
+	defer cancel()
+
+	changes, err := m.celestialsApi.Changes(requestCtx, n.name, celestials.WithOnlyHead())
+	if err != nil {
+		return err
+	}
+	n.health.setHead(changes.Head)
+	m.metrics.setHead(n.name, changes.Head, n.state.ChangeId)
+	return nil
+}
@@ -0,0 +1,96 @@
+package module
+
+import (
+	"context"
+	"time"
+)
+
+// IsLeader - returns true if module syncs changes. It's always true if leader election is disabled.
+func (m *Module) IsLeader() bool {
+	return m.leaderLock == nil || m.leader.Load()
+}
+
+// elect - tries to acquire leader lock periodically. Leader starts sync loops of networks and checks that the lock
+// is still held. If the lock is lost, sync loops are stopped and the module becomes a follower again.
+func (m *Module) elect(ctx context.Context) {
+	var stop context.CancelFunc
+	defer func() {
+		m.resign(stop)
+	}()
+
+	ticker := time.NewTicker(m.leaderCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		if stop != nil {
+			if err := m.checkLeadership(ctx); err != nil {
+				m.Log.Err(err).Msg("leadership is lost")
+				m.resign(stop)
+				stop = nil
+			}
+		} else {
+			cancel, err := m.lead(ctx)
+			if err != nil {
+				m.Log.Err(err).Msg("leader election")
+			}
+			stop = cancel
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// lead - acquires leader lock and starts sync loops. It returns function which stops sync loops
+// or nil if the lock is held by another instance.
+func (m *Module) lead(ctx context.Context) (context.CancelFunc, error) {
+	leader, err := m.acquireLeadership(ctx)
+	if err != nil || !leader {
+		return nil, err
+	}
+
+	syncCtx, cancel := context.WithCancel(ctx)
+	if err := m.startNetworks(syncCtx); err != nil {
+		m.resign(cancel)
+		return nil, err
+	}
+	m.leader.Store(true)
+	m.Log.Info().Msg("became leader")
+	return cancel, nil
+}
+
+// resign - stops sync loops and releases leader lock. Waiters of syncs receive ErrNotLeader.
+func (m *Module) resign(stop context.CancelFunc) {
+	if stop != nil {
+		stop()
+		m.syncs.Wait()
+	}
+	m.controlMx.Lock()
+	m.leader.Store(false)
+	m.cancelWaiters(ErrNotLeader)
+	m.controlMx.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.databaseTimeout)
+	defer cancel()
+
+	if err := m.leaderLock.Unlock(ctx); err != nil {
+		m.Log.Err(err).Msg("release leader lock")
+	}
+}
+
+func (m *Module) acquireLeadership(ctx context.Context) (bool, error) {
+	requestCtx, cancel := context.WithTimeout(ctx, m.databaseTimeout)
+	defer cancel()
+
+	return m.leaderLock.TryLock(requestCtx)
+}
+
+func (m *Module) checkLeadership(ctx context.Context) error {
+	requestCtx, cancel := context.WithTimeout(ctx, m.databaseTimeout)
+	defer cancel()
+
+	return m.leaderLock.Check(requestCtx)
+}
@@ -0,0 +1,173 @@
+package module
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "celestials"
+
+// metrics - prometheus collectors of module. All methods are no-op for nil receiver.
+type metrics struct {
+	syncDuration    *prometheus.HistogramVec
+	saveDuration    *prometheus.HistogramVec
+	fetched         *prometheus.CounterVec
+	applied         *prometheus.CounterVec
+	skipped         *prometheus.CounterVec
+	addressFailures *prometheus.CounterVec
+	quarantined     *prometheus.CounterVec
+	changeId        *prometheus.GaugeVec
+	head            *prometheus.GaugeVec
+	lag             *prometheus.GaugeVec
+
+	registerer prometheus.Registerer
+}
+
+func newMetrics(reg prometheus.Registerer, indexerName string) *metrics {
+	labels := prometheus.Labels{"indexer": indexerName}
+	variableLabels := []string{"network"}
+
+	return &metrics{
+		registerer: reg,
+		syncDuration: register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   metricsNamespace,
+			Name:        "sync_duration_seconds",
+			Help:        "Duration of sync iteration",
+			ConstLabels: labels,
+			Buckets:     prometheus.ExponentialBuckets(0.01, 2, 15),
+		}, variableLabels)),
+		saveDuration: register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   metricsNamespace,
+			Name:        "save_duration_seconds",
+			Help:        "Duration of saving changes to database",
+			ConstLabels: labels,
+			Buckets:     prometheus.DefBuckets,
+		}, variableLabels)),
+		fetched: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Name:        "changes_fetched_total",
+			Help:        "Count of new changes received from API",
+			ConstLabels: labels,
+		}, variableLabels)),
+		applied: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Name:        "changes_applied_total",
+			Help:        "Count of changes applied to database",
+			ConstLabels: labels,
+		}, variableLabels)),
+		skipped: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Name:        "changes_skipped_total",
+			Help:        "Count of received changes which were not applied",
+			ConstLabels: labels,
+		}, variableLabels)),
+		addressFailures: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Name:        "address_handler_failures_total",
+			Help:        "Count of address handler errors",
+			ConstLabels: labels,
+		}, variableLabels)),
+		quarantined: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Name:        "changes_quarantined_total",
+			Help:        "Count of changes with unknown status",
+			ConstLabels: labels,
+		}, variableLabels)),
+		changeId: register(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   metricsNamespace,
+			Name:        "change_id",
+			Help:        "Id of the last applied change",
+			ConstLabels: labels,
+		}, variableLabels)),
+		head: register(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   metricsNamespace,
+			Name:        "head_change_id",
+			Help:        "Id of the last change known by API",
+			ConstLabels: labels,
+		}, variableLabels)),
+		lag: register(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   metricsNamespace,
+			Name:        "lag",
+			Help:        "Difference between API head and the last applied change id",
+			ConstLabels: labels,
+		}, variableLabels)),
+	}
+}
+
+// register - registers collector or returns already registered one with the same description
+func register[T prometheus.Collector](reg prometheus.Registerer, collector T) T {
+	if err := reg.Register(collector); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if errors.As(err, &alreadyRegistered) {
+			if existing, ok := alreadyRegistered.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return collector
+}
+
+func (m *metrics) observeSync(network string, start time.Time) {
+	if m == nil {
+		return
+	}
+	m.syncDuration.WithLabelValues(network).Observe(time.Since(start).Seconds())
+}
+
+func (m *metrics) observeSave(network string, start time.Time) {
+	if m == nil {
+		return
+	}
+	m.saveDuration.WithLabelValues(network).Observe(time.Since(start).Seconds())
+}
+
+func (m *metrics) changes(network string, fetched, applied int) {
+	if m == nil {
+		return
+	}
+	m.fetched.WithLabelValues(network).Add(float64(fetched))
+	m.applied.WithLabelValues(network).Add(float64(applied))
+	if fetched > applied {
+		m.skipped.WithLabelValues(network).Add(float64(fetched - applied))
+	}
+}
+
+func (m *metrics) addressFailure(network string) {
+	if m == nil {
+		return
+	}
+	m.addressFailures.WithLabelValues(network).Inc()
+}
+
+func (m *metrics) quarantine(network string) {
+	if m == nil {
+		return
+	}
+	m.quarantined.WithLabelValues(network).Inc()
+}
+
+func (m *metrics) setHead(network string, head, changeId int64) {
+	if m == nil {
+		return
+	}
+	m.head.WithLabelValues(network).Set(float64(head))
+	m.setLag(network, head, changeId)
+}
+
+// setChangeId - sets the last applied change id. Lag is updated only if head is known (greater than zero).
+func (m *metrics) setChangeId(network string, changeId, head int64) {
+	if m == nil {
+		return
+	}
+	m.changeId.WithLabelValues(network).Set(float64(changeId))
+	m.setLag(network, head, changeId)
+}
+
+func (m *metrics) setLag(network string, head, changeId int64) {
+	if head > 0 {
+		m.lag.WithLabelValues(network).Set(float64(max(head-changeId, 0)))
+	}
+}
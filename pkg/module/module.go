@@ -3,7 +3,8 @@ package module
 import (
 	"context"
 	"database/sql"
-	"maps"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	celestials "github.com/celenium-io/celestial-module/pkg/api"
@@ -22,21 +23,44 @@ type AddressHandler func(ctx context.Context, address string) (uint64, error)
 type Module struct {
 	modules.BaseModule
 
-	celestialsApi  celestials.API
-	addressHandler AddressHandler
-	states         storage.ICelestialState
-	celestials     storage.ICelestial
-	tx             sdk.Transactable
-	state          storage.CelestialState
-
-	celestialsDatasource config.DataSource
-	indexerName          string
-	network              string
-	indexPeriod          time.Duration
-	databaseTimeout      time.Duration
-	limit                int64
+	celestialsApi       celestials.API
+	addressHandler      AddressHandler
+	batchAddressHandler BatchAddressHandler
+	states              storage.ICelestialState
+	celestials          storage.ICelestial
+	cache               CacheInvalidator
+	tx                  sdk.Transactable
+	networks            []*networkSync
+	quarantined         atomic.Int64
+	metrics             *metrics
+	syncs               sync.WaitGroup
+	leaderLock          storage.ILeaderLock
+	leader              atomic.Bool
+	paused              atomic.Bool
+	controlMx           sync.Mutex
+	publishMx           sync.Mutex
+
+	indexerName     string
+	indexPeriod     time.Duration
+	minIndexPeriod  time.Duration
+	maxIndexPeriod  time.Duration
+	databaseTimeout time.Duration
+	requestTimeout  time.Duration
+	retryPeriod     time.Duration
+	limit           int64
+	prefetch        int
+	addressWorkers  int
+
+	unknownStatusPolicy UnknownStatusPolicy
+	fallbackStatus      storage.Status
+
+	maxLag             int64
+	healthStallTimeout time.Duration
+	leaderCheckPeriod  time.Duration
 }
 
+// New - creates module which receives changes from Celestials API located by the data source URL.
+// The passed network is indexed by default, other networks can be added by WithNetwork option.
 func New(
 	celestialsDatasource config.DataSource,
 	addressHandler AddressHandler,
@@ -46,22 +70,65 @@ func New(
 	indexerName string,
 	network string,
 	opts ...ModuleOption,
+) *Module {
+	opts = append([]ModuleOption{
+		WithRequestTimeout(time.Second * time.Duration(celestialsDatasource.Timeout)),
+	}, opts...)
+
+	module := NewWithAPI(
+		nil,
+		addressHandler,
+		celestials,
+		state,
+		tx,
+		indexerName,
+		network,
+		opts...,
+	)
+	if module.celestialsApi == nil {
+		apiOpts := make([]v1.ApiOption, 0)
+		if module.metrics != nil {
+			apiOpts = append(apiOpts, v1.WithMetrics(module.metrics.registerer))
+		}
+		module.celestialsApi = v1.New(celestialsDatasource.URL, apiOpts...)
+	}
+	return module
+}
+
+// NewWithAPI - creates module which receives changes from the passed Celestials API implementation
+func NewWithAPI(
+	api celestials.API,
+	addressHandler AddressHandler,
+	celestials storage.ICelestial,
+	state storage.ICelestialState,
+	tx sdk.Transactable,
+	indexerName string,
+	network string,
+	opts ...ModuleOption,
 ) *Module {
 	module := Module{
-		BaseModule:           modules.New("celestials"),
-		celestials:           celestials,
-		states:               state,
-		tx:                   tx,
-		celestialsApi:        v1.New(celestialsDatasource.URL),
-		indexerName:          indexerName,
-		network:              network,
-		indexPeriod:          time.Minute,
-		databaseTimeout:      time.Minute,
-		limit:                100,
-		celestialsDatasource: celestialsDatasource,
-		addressHandler:       addressHandler,
+		BaseModule:        modules.New("celestials"),
+		celestials:        celestials,
+		states:            state,
+		tx:                tx,
+		celestialsApi:     api,
+		indexerName:       indexerName,
+		networks:          []*networkSync{newNetworkSync(network, nil)},
+		indexPeriod:       time.Minute,
+		databaseTimeout:   time.Minute,
+		requestTimeout:    time.Minute,
+		retryPeriod:       time.Minute,
+		limit:             100,
+		prefetch:          1,
+		addressWorkers:    1,
+		fallbackStatus:    storage.StatusNOTVERIFIED,
+		maxLag:            1000,
+		leaderCheckPeriod: time.Second * 10,
+		addressHandler:    addressHandler,
 	}
 
+	module.CreateOutput(ChangesOutput)
+
 	for i := range opts {
 		opts[i](&module)
 	}
@@ -72,142 +139,259 @@ func New(
 func (m *Module) Close() error {
 	m.Log.Info().Msg("closing scanner...")
 	m.G.Wait()
+	m.syncs.Wait()
 
 	return nil
 }
 
 func (m *Module) Start(ctx context.Context) {
-	if m.addressHandler == nil {
-		panic("nil address handler")
+	for _, n := range m.networks {
+		if m.handler(n) == nil && m.batchHandler(n) == nil {
+			panic("nil address handler of network " + n.name)
+		}
 	}
-	if err := m.getState(ctx); err != nil {
-		m.Log.Err(err).Msg("state receiving")
+	if m.celestialsApi == nil {
+		panic("nil celestials api")
+	}
+	m.checkInputs()
+
+	if m.leaderLock != nil {
+		m.Log.Info().Strs("networks", m.Networks()).Msg("starting leader election...")
+		m.G.GoCtx(ctx, m.elect)
 		return
 	}
-	m.Log.Info().Msg("starting scanner...")
-	m.G.GoCtx(ctx, m.receive)
+
+	if err := m.startNetworks(ctx); err != nil {
+		m.Log.Err(err).Msg("state receiving")
+	}
+}
+
+// startNetworks - receives sync states and starts sync loop of every network
+func (m *Module) startNetworks(ctx context.Context) error {
+	for _, n := range m.networks {
+		if err := m.getState(ctx, n); err != nil {
+			return errors.Wrap(err, n.name)
+		}
+	}
+
+	m.Log.Info().Strs("networks", m.Networks()).Msg("starting scanner...")
+	for _, n := range m.networks {
+		n.health.mx.Lock()
+		n.health.startTime = time.Now().UTC()
+		n.health.mx.Unlock()
+
+		m.syncs.Go(func() {
+			m.receive(ctx, n)
+		})
+	}
+	return nil
 }
 
-func (m *Module) getState(ctx context.Context) error {
+func (m *Module) getState(ctx context.Context, n *networkSync) error {
 	requestCtx, cancel := context.WithTimeout(ctx, m.databaseTimeout)
 	defer cancel()
 
-	state, err := m.states.ByName(requestCtx, m.indexerName)
+	state, err := m.states.ByName(requestCtx, m.indexerName, n.name)
 	if err != nil {
 		if !errors.Is(err, sql.ErrNoRows) {
 			return errors.Wrap(err, "state by name")
 		}
-		m.state = storage.CelestialState{
+		n.state = storage.CelestialState{
 			Name:     m.indexerName,
+			Network:  n.name,
 			ChangeId: 0,
 		}
-		return m.states.Save(ctx, &m.state)
+		if err := m.states.Save(ctx, &n.state); err != nil {
+			return errors.Wrap(err, "save state")
+		}
+	} else {
+		n.state = state
+		m.metrics.setChangeId(n.name, n.state.ChangeId, 0)
+		n.health.setChangeId(n.state.ChangeId)
 	}
-	m.state = state
-	return nil
+	return m.loadResync(ctx, n)
 }
 
-func (m *Module) receive(ctx context.Context) {
-	if err := m.sync(ctx); err != nil {
-		m.Log.Err(err).Msg("sync")
-	}
+func (m *Module) receive(ctx context.Context, n *networkSync) {
+	timer := time.NewTimer(m.syncAndSchedule(ctx, n))
+	defer timer.Stop()
 
-	ticker := time.NewTicker(m.indexPeriod)
-	defer ticker.Stop()
+	retryTicker := time.NewTicker(m.retryPeriod)
+	defer retryTicker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			if err := m.sync(ctx); err != nil {
-				m.Log.Err(err).Msg("sync")
+		case <-timer.C:
+			if m.Paused() {
+				timer.Reset(m.indexPeriod)
+				continue
+			}
+			timer.Reset(m.syncAndSchedule(ctx, n))
+		case <-n.trigger:
+			if !m.Paused() {
+				timer.Reset(m.syncAndSchedule(ctx, n))
+			}
+		case req := <-n.rollback:
+			err := m.rollback(ctx, n, req.changeId)
+			req.result <- err
+			if err == nil && !m.Paused() {
+				timer.Reset(0)
+			}
+		case req := <-n.repair:
+			req.result <- m.applyRepair(ctx, n, req.celestials)
+		case <-retryTicker.C:
+			// retries are suspended during resync: they would write to the live table which is replaced by the swap
+			if m.Paused() || n.resync.Load() != nil {
+				continue
+			}
+			if err := m.retryFailedChanges(ctx, n); err != nil {
+				m.Log.Err(err).Str("network", n.name).Msg("retry failed changes")
 			}
 		}
 	}
 }
 
-func (m *Module) getChanges(ctx context.Context) (celestials.Changes, error) {
-	requestCtx, cancel := context.WithTimeout(ctx, time.Second*time.Duration(m.celestialsDatasource.Timeout))
+func (m *Module) getChanges(ctx context.Context, n *networkSync, fromChangeId int64) (celestials.Changes, error) {
+	requestCtx, cancel := context.WithTimeout(ctx, m.requestTimeout)
 	defer cancel()
 
 	return m.celestialsApi.Changes(
 		requestCtx,
-		m.network,
-		celestials.WithFromChangeId(m.state.ChangeId),
+		n.name,
+		celestials.WithFromChangeId(fromChangeId),
 		celestials.WithImages(),
 		celestials.WithLimit(m.limit),
 	)
 }
 
-func (m *Module) sync(ctx context.Context) error {
-	m.Log.Debug().Msg("start syncing...")
-
-	var end bool
+// sync - receives changes until the API head. Next page is prefetched while the current one is processed,
+// pages are saved strictly in order.
+func (m *Module) sync(ctx context.Context, n *networkSync) error {
+	m.Log.Debug().Str("network", n.name).Msg("start syncing...")
+	defer m.metrics.observeSync(n.name, time.Now())
+
+	fetchCtx, cancel := context.WithCancel(ctx)
+	pages := m.fetch(fetchCtx, n)
+	defer func() {
+		cancel()
+		for range pages {
+		}
+	}()
 
-	for !end {
-		changes, err := m.getChanges(ctx)
-		if err != nil {
-			return errors.Wrap(err, "get changes")
+	for p := range pages {
+		if p.err != nil {
+			return errors.Wrap(p.err, "get changes")
+		}
+		if err := m.processPage(ctx, n, p.changes); err != nil {
+			return err
 		}
-		log.Info().
-			Int("changes_count", len(changes.Changes)).
-			Int64("head", changes.Head).
-			Msg("received changes")
+	}
 
-		cids := make(map[string]storage.Celestial)
-		addressIds := make(map[uint64]struct{})
+	m.Log.Debug().Str("network", n.name).Msg("end syncing...")
+	return nil
+}
 
-		var lastId int64
-		for i := range changes.Changes {
-			if m.state.ChangeId >= changes.Changes[i].ChangeID {
-				continue
-			}
-			lastId = changes.Changes[i].ChangeID
+func (m *Module) processPage(ctx context.Context, n *networkSync, changes celestials.Changes) error {
+	log.Info().
+		Str("network", n.name).
+		Int("changes_count", len(changes.Changes)).
+		Int64("head", changes.Head).
+		Msg("received changes")
+	m.metrics.setHead(n.name, changes.Head, n.state.ChangeId)
+	n.health.setHead(changes.Head)
 
-			status, err := storage.ParseStatus(changes.Changes[i].Status)
-			if err != nil {
-				return err
-			}
-			addressId, err := m.addressHandler(ctx, changes.Changes[i].Address)
-			if err != nil {
-				m.Log.Err(err).Msg("address handler")
-				continue
-			}
+	b, lastId, fetched, err := m.buildBatch(ctx, n, n.state.ChangeId, changes)
+	if err != nil {
+		return err
+	}
 
-			if status == storage.StatusPRIMARY {
-				addressIds[addressId] = struct{}{}
-			}
+	if lastId <= n.state.ChangeId {
+		return nil
+	}
 
-			cids[changes.Changes[i].CelestialID] = storage.Celestial{
-				Id:        changes.Changes[i].CelestialID,
-				ImageUrl:  changes.Changes[i].ImageURL,
-				AddressId: addressId,
-				ChangeId:  changes.Changes[i].ChangeID,
-				Status:    status,
-			}
+	prevId := n.state.ChangeId
+	n.state.ChangeId = lastId
+
+	if err := m.save(ctx, n, b); err != nil {
+		n.state.ChangeId = prevId
+		return errors.Wrap(err, "save")
+	}
+	m.metrics.changes(n.name, fetched, len(b.history))
+	m.metrics.setChangeId(n.name, n.state.ChangeId, changes.Head)
+	n.health.setChangeId(n.state.ChangeId)
+	log.Debug().
+		Str("network", n.name).
+		Int("changes_count", len(b.celestials)).
+		Int("failed_count", len(b.failed)).
+		Int64("head", n.state.ChangeId).
+		Msg("saved changes")
+	return nil
+}
+
+// buildBatch - parses statuses and resolves addresses of changes which are newer than passed change id.
+// It returns batch, id of the last change and count of new changes.
+func (m *Module) buildBatch(ctx context.Context, n *networkSync, fromChangeId int64, changes celestials.Changes) (b *batch, lastId int64, fetched int, err error) {
+	b = newBatch(n.name)
+	pending := make([]pendingChange, 0, len(changes.Changes))
+
+	for i := range changes.Changes {
+		if fromChangeId >= changes.Changes[i].ChangeID {
+			continue
 		}
+		lastId = changes.Changes[i].ChangeID
+		fetched++
 
-		if lastId > m.state.ChangeId {
-			m.state.ChangeId = lastId
+		status, statusErr := storage.ParseStatus(changes.Changes[i].Status)
+		if statusErr != nil {
+			if m.unknownStatusPolicy == UnknownStatusFail {
+				return nil, 0, 0, statusErr
+			}
+			m.quarantine(n.name, changes.Changes[i], statusErr)
+			b.fail(m.newFailedChange(n.name, changes.Changes[i], statusErr))
+			if m.unknownStatusPolicy == UnknownStatusQuarantine {
+				continue
+			}
+			status = m.fallbackStatus
+		}
+		pending = append(pending, pendingChange{
+			change:      changes.Changes[i],
+			status:      status,
+			quarantined: statusErr != nil,
+		})
+	}
 
-			if err := m.save(ctx, cids, addressIds); err != nil {
-				return errors.Wrap(err, "save")
+	m.resolveAddresses(ctx, n, pending)
+
+	for _, pc := range pending {
+		if pc.err != nil {
+			m.Log.Err(pc.err).
+				Str("network", n.name).
+				Str("celestial_id", pc.change.CelestialID).
+				Int64("change_id", pc.change.ChangeID).
+				Msg("address handler")
+			m.metrics.addressFailure(n.name)
+			if !pc.quarantined {
+				b.fail(m.newFailedChange(n.name, pc.change, pc.err))
 			}
-			log.Debug().
-				Int("changes_count", len(cids)).
-				Int64("head", m.state.ChangeId).
-				Msg("saved changes")
+			continue
 		}
 
-		end = len(changes.Changes) < int(m.limit)
+		b.apply(storage.Celestial{
+			Id:        pc.change.CelestialID,
+			ImageUrl:  pc.change.ImageURL,
+			AddressId: pc.addressId,
+			ChangeId:  pc.change.ChangeID,
+			Status:    pc.status,
+		})
 	}
-
-	m.Log.Debug().Msg("end syncing...")
-	return nil
+	return b, lastId, fetched, nil
 }
 
-func (m *Module) save(ctx context.Context, cids map[string]storage.Celestial, addressIds map[uint64]struct{}) error {
+func (m *Module) save(ctx context.Context, n *networkSync, b *batch) error {
+	defer m.metrics.observeSave(n.name, time.Now())
+
 	requestCtx, cancel := context.WithTimeout(ctx, m.databaseTimeout)
 	defer cancel()
 
@@ -217,17 +401,19 @@ func (m *Module) save(ctx context.Context, cids map[string]storage.Celestial, ad
 	}
 	defer tx.Close(requestCtx)
 
-	if err := tx.UpdateStatusForAddress(ctx, maps.Keys(addressIds)); err != nil {
-		return tx.HandleError(requestCtx, errors.Wrap(err, "update primary statuses"))
+	if err := m.saveBatch(requestCtx, tx, b); err != nil {
+		return tx.HandleError(requestCtx, err)
 	}
 
-	if err := tx.SaveCelestials(requestCtx, maps.Values(cids)); err != nil {
-		return tx.HandleError(requestCtx, errors.Wrap(err, "save celestials"))
+	if err := tx.UpdateState(requestCtx, &n.state); err != nil {
+		return tx.HandleError(requestCtx, errors.Wrap(err, "update state"))
 	}
 
-	if err := tx.UpdateState(requestCtx, &m.state); err != nil {
-		return tx.HandleError(requestCtx, errors.Wrap(err, "update state"))
+	if err := tx.Flush(requestCtx); err != nil {
+		return errors.Wrap(err, "flush")
 	}
 
-	return tx.Flush(requestCtx)
+	m.invalidate(b)
+	m.publish(ctx, b)
+	return nil
 }
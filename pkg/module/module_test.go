@@ -3,17 +3,26 @@ package module
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	celestials "github.com/celenium-io/celestial-module/pkg/api"
 	celestialsMock "github.com/celenium-io/celestial-module/pkg/api/mock"
 	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/celenium-io/celestial-module/pkg/storage/cache"
+	storageMock "github.com/celenium-io/celestial-module/pkg/storage/mock"
 	pg "github.com/celenium-io/celestial-module/pkg/storage/postgres"
 	"github.com/dipdup-io/go-lib/config"
 	"github.com/dipdup-io/go-lib/database"
+	"github.com/dipdup-net/indexer-sdk/pkg/modules"
 	"github.com/dipdup-net/indexer-sdk/pkg/storage/postgres"
 	"github.com/go-testfixtures/testfixtures/v3"
+	"github.com/goccy/go-json"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
 )
@@ -52,12 +61,15 @@ func (s *ModuleTestSuite) SetupSuite() {
 		if err := pg.CreateTypes(ctx, conn); err != nil {
 			return err
 		}
-		if err := database.CreateTables(ctx, conn, new(storage.Celestial), new(storage.CelestialState)); err != nil {
+		if err := database.CreateTables(ctx, conn, new(storage.Celestial), new(storage.CelestialState), new(storage.CelestialFailedChange), new(storage.CelestialHistory)); err != nil {
 			if err := conn.Close(); err != nil {
 				return err
 			}
 			return err
 		}
+		if err := pg.CreateHypertables(ctx, conn); err != nil {
+			return err
+		}
 		return nil
 	}
 
@@ -89,7 +101,7 @@ func (s *ModuleTestSuite) TearDownSuite() {
 	s.ctrl.Finish()
 }
 
-func (s *ModuleTestSuite) TestSync() {
+func (s *ModuleTestSuite) loadFixtures() {
 	db, err := sql.Open("pgx", s.psqlContainer.GetDSN())
 	s.Require().NoError(err)
 
@@ -102,6 +114,10 @@ func (s *ModuleTestSuite) TestSync() {
 	s.Require().NoError(err)
 	s.Require().NoError(fixtures.Load())
 	s.Require().NoError(db.Close())
+}
+
+func (s *ModuleTestSuite) TestSync() {
+	s.loadFixtures()
 
 	s.api.EXPECT().
 		Changes(
@@ -144,26 +160,1304 @@ func (s *ModuleTestSuite) TestSync() {
 		testIndexerName,
 		network,
 		WithLimit(10),
+		WithAPI(s.api),
 	)
-	m.celestialsApi = s.api
 
-	err = m.getState(ctx)
+	err := m.getState(ctx, m.networks[0])
 	s.Require().NoError(err)
 
-	err = m.sync(ctx)
+	err = m.sync(ctx, m.networks[0])
 	s.Require().NoError(err)
 
-	st, err := s.celestialState.ByName(ctx, testIndexerName)
+	st, err := s.celestialState.ByName(ctx, testIndexerName, network)
 	s.Require().NoError(err)
 	s.Require().EqualValues(4, st.ChangeId)
 	s.Require().EqualValues(testIndexerName, st.Name)
 
-	item, err := s.celestials.ById(ctx, "test")
+	item, err := s.celestials.ById(ctx, network, "test")
 	s.Require().NoError(err)
 	s.Require().EqualValues("image_url", item.ImageUrl)
 	s.Require().EqualValues("test", item.Id)
 	s.Require().EqualValues(4, item.ChangeId)
 	s.Require().EqualValues(1, item.AddressId)
+
+	history, err := pg.NewCelestialHistory(s.storage.Connection()).ById(ctx, network, "test", 0, 10)
+	s.Require().NoError(err)
+	s.Require().Len(history, 1)
+	s.Require().EqualValues(4, history[0].ChangeId)
+	s.Require().EqualValues(1, history[0].AddressId)
+	s.Require().EqualValues(storage.StatusPRIMARY, history[0].Status)
+}
+
+func (s *ModuleTestSuite) TestSyncPipelined() {
+	s.loadFixtures()
+
+	first := s.api.EXPECT().
+		Changes(gomock.Any(), network, gomock.Any()).
+		Times(1).
+		Return(celestials.Changes{
+			Head: 6,
+			Changes: []celestials.Change{
+				{CelestialID: "pipe 1", Address: "address 1", ChangeID: 4, Status: "VERIFIED"},
+				{CelestialID: "pipe 2", Address: "address 2", ChangeID: 5, Status: "PRIMARY"},
+			},
+		}, nil)
+	s.api.EXPECT().
+		Changes(gomock.Any(), network, gomock.Any()).
+		Times(1).
+		After(first).
+		Return(celestials.Changes{
+			Head: 6,
+			Changes: []celestials.Change{
+				{CelestialID: "pipe 1", Address: "address 3", ChangeID: 6, Status: "PRIMARY"},
+			},
+		}, nil)
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	m := NewWithAPI(
+		s.api,
+		func(ctx context.Context, address string) (uint64, error) {
+			switch address {
+			case "address 1":
+				return 11, nil
+			case "address 2":
+				return 12, nil
+			default:
+				return 13, nil
+			}
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithLimit(2),
+		WithPrefetch(2),
+		WithAddressWorkers(4),
+	)
+
+	s.Require().NoError(m.getState(ctx, m.networks[0]))
+	s.Require().NoError(m.sync(ctx, m.networks[0]))
+
+	st, err := s.celestialState.ByName(ctx, testIndexerName, network)
+	s.Require().NoError(err)
+	s.Require().EqualValues(6, st.ChangeId)
+
+	item, err := s.celestials.ById(ctx, network, "pipe 1")
+	s.Require().NoError(err)
+	s.Require().EqualValues(6, item.ChangeId)
+	s.Require().EqualValues(13, item.AddressId)
+	s.Require().EqualValues(storage.StatusPRIMARY, item.Status)
+
+	item, err = s.celestials.ById(ctx, network, "pipe 2")
+	s.Require().NoError(err)
+	s.Require().EqualValues(12, item.AddressId)
+}
+
+func (s *ModuleTestSuite) TestSyncBatchAddressHandler() {
+	s.loadFixtures()
+
+	s.api.EXPECT().
+		Changes(gomock.Any(), network, gomock.Any()).
+		Times(1).
+		Return(celestials.Changes{
+			Head: 7,
+			Changes: []celestials.Change{
+				{CelestialID: "batch 1", Address: "address 1", ChangeID: 4, Status: "VERIFIED"},
+				{CelestialID: "batch 2", Address: "address 1", ChangeID: 5, Status: "VERIFIED"},
+				{CelestialID: "batch 3", Address: "bad", ChangeID: 6, Status: "VERIFIED"},
+				{CelestialID: "batch 4", Address: "address 2", ChangeID: 7, Status: "VERIFIED"},
+			},
+		}, nil)
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	var batchCalls int
+	m := NewWithAPI(
+		s.api,
+		func(ctx context.Context, address string) (uint64, error) {
+			if address == "address 2" {
+				return 22, nil
+			}
+			return 0, errors.New("unexpected address")
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithLimit(10),
+		WithBatchAddressHandler(func(ctx context.Context, addresses []string) (map[string]uint64, error) {
+			batchCalls++
+			s.Require().ElementsMatch([]string{"address 1", "bad", "address 2"}, addresses)
+			return map[string]uint64{"address 1": 11}, AddressErrors{"bad": errors.New("invalid address")}
+		}),
+	)
+
+	s.Require().NoError(m.getState(ctx, m.networks[0]))
+	s.Require().NoError(m.sync(ctx, m.networks[0]))
+	s.Require().Equal(1, batchCalls)
+
+	st, err := s.celestialState.ByName(ctx, testIndexerName, network)
+	s.Require().NoError(err)
+	s.Require().EqualValues(7, st.ChangeId)
+
+	for id, addressId := range map[string]uint64{"batch 1": 11, "batch 2": 11, "batch 4": 22} {
+		item, err := s.celestials.ById(ctx, network, id)
+		s.Require().NoError(err, id)
+		s.Require().EqualValues(addressId, item.AddressId, id)
+	}
+
+	_, err = s.celestials.ById(ctx, network, "batch 3")
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+
+	items, err := pg.NewCelestialFailedChanges(s.storage.Connection()).List(ctx, network, 10, 0)
+	s.Require().NoError(err)
+	s.Require().Len(items, 1)
+	s.Require().EqualValues("batch 3", items[0].CelestialId)
+	s.Require().EqualValues("invalid address", items[0].Error)
+
+	tx, err := pg.BeginCelestialTransaction(ctx, s.storage.Transactable)
+	s.Require().NoError(err)
+	s.Require().NoError(tx.DeleteFailedChanges(ctx, network, 6))
+	s.Require().NoError(tx.Flush(ctx))
+	s.Require().NoError(tx.Close(ctx))
+}
+
+func (s *ModuleTestSuite) TestSyncMultiNetwork() {
+	s.loadFixtures()
+
+	const testnet = "mocha-4"
+
+	for _, chainId := range []string{network, testnet} {
+		s.api.EXPECT().
+			Changes(gomock.Any(), chainId, gomock.Any()).
+			Times(1).
+			Return(celestials.Changes{
+				Head: 4,
+				Changes: []celestials.Change{
+					{
+						CelestialID: "name 3",
+						Address:     chainId + "_address",
+						ChangeID:    4,
+						Status:      "PRIMARY",
+					},
+				},
+			}, nil)
+	}
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	m := NewWithAPI(
+		s.api,
+		func(ctx context.Context, address string) (uint64, error) {
+			return 1, nil
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithLimit(10),
+		WithNetwork(testnet, func(ctx context.Context, address string) (uint64, error) {
+			return 100, nil
+		}),
+	)
+	s.Require().Equal([]string{network, testnet}, m.Networks())
+
+	for _, n := range m.networks {
+		s.Require().NoError(m.getState(ctx, n))
+		s.Require().NoError(m.sync(ctx, n))
+	}
+
+	st, err := s.celestialState.ByName(ctx, testIndexerName, network)
+	s.Require().NoError(err)
+	s.Require().EqualValues(4, st.ChangeId)
+
+	st, err = s.celestialState.ByName(ctx, testIndexerName, testnet)
+	s.Require().NoError(err)
+	s.Require().EqualValues(4, st.ChangeId)
+
+	item, err := s.celestials.ById(ctx, network, "name 3")
+	s.Require().NoError(err)
+	s.Require().EqualValues(1, item.AddressId)
+
+	item, err = s.celestials.ById(ctx, testnet, "name 3")
+	s.Require().NoError(err)
+	s.Require().EqualValues(100, item.AddressId)
+	s.Require().EqualValues(testnet, item.Network)
+
+	health := m.Health()
+	s.Require().Len(health.Networks, 2)
+	s.Require().Equal(network, health.Networks[0].Network)
+	s.Require().Equal(testnet, health.Networks[1].Network)
+}
+
+func (s *ModuleTestSuite) TestSyncWithFailedAddress() {
+	s.loadFixtures()
+
+	s.api.EXPECT().
+		Changes(
+			gomock.Any(),
+			network,
+			gomock.Any(),
+		).
+		Times(1).
+		Return(celestials.Changes{
+			Head: 5,
+			Changes: []celestials.Change{
+				{
+					CelestialID: "failed",
+					Address:     "bad_address",
+					ImageURL:    "image_url",
+					ChangeID:    4,
+					Status:      "PRIMARY",
+				}, {
+					CelestialID: "success",
+					Address:     "good_address",
+					ChangeID:    5,
+					Status:      "VERIFIED",
+				},
+			},
+		}, nil)
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	var resolvable bool
+	registry := prometheus.NewRegistry()
+	m := NewWithAPI(
+		s.api,
+		func(ctx context.Context, address string) (uint64, error) {
+			if address == "bad_address" && !resolvable {
+				return 0, errors.New("unknown address")
+			}
+			return 10, nil
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithLimit(10),
+		WithRetryPeriod(time.Millisecond),
+		WithMetrics(registry),
+	)
+
+	err := m.getState(ctx, m.networks[0])
+	s.Require().NoError(err)
+
+	err = m.sync(ctx, m.networks[0])
+	s.Require().NoError(err)
+
+	st, err := s.celestialState.ByName(ctx, testIndexerName, network)
+	s.Require().NoError(err)
+	s.Require().EqualValues(5, st.ChangeId)
+
+	_, err = s.celestials.ById(ctx, network, "failed")
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+
+	failedChanges := pg.NewCelestialFailedChanges(s.storage.Connection())
+	items, err := failedChanges.List(ctx, network, 10, 0)
+	s.Require().NoError(err)
+	s.Require().Len(items, 1)
+	s.Require().EqualValues(4, items[0].ChangeId)
+	s.Require().EqualValues("failed", items[0].CelestialId)
+	s.Require().EqualValues("bad_address", items[0].Address)
+	s.Require().EqualValues("PRIMARY", items[0].Status)
+	s.Require().EqualValues("unknown address", items[0].Error)
+	s.Require().EqualValues(1, items[0].Attempts)
+
+	s.Require().EqualValues(2, testutil.ToFloat64(m.metrics.fetched.WithLabelValues(network)))
+	s.Require().EqualValues(1, testutil.ToFloat64(m.metrics.applied.WithLabelValues(network)))
+	s.Require().EqualValues(1, testutil.ToFloat64(m.metrics.skipped.WithLabelValues(network)))
+	s.Require().EqualValues(1, testutil.ToFloat64(m.metrics.addressFailures.WithLabelValues(network)))
+	s.Require().EqualValues(5, testutil.ToFloat64(m.metrics.changeId.WithLabelValues(network)))
+	s.Require().EqualValues(5, testutil.ToFloat64(m.metrics.head.WithLabelValues(network)))
+	s.Require().EqualValues(0, testutil.ToFloat64(m.metrics.lag.WithLabelValues(network)))
+	s.Require().EqualValues(1, testutil.CollectAndCount(m.metrics.syncDuration))
+
+	time.Sleep(time.Millisecond * 100)
+	resolvable = true
+	s.Require().NoError(m.retryFailedChanges(ctx, m.networks[0]))
+
+	item, err := s.celestials.ById(ctx, network, "failed")
+	s.Require().NoError(err)
+	s.Require().EqualValues(4, item.ChangeId)
+	s.Require().EqualValues(10, item.AddressId)
+	s.Require().EqualValues("image_url", item.ImageUrl)
+	s.Require().EqualValues(storage.StatusPRIMARY, item.Status)
+
+	items, err = failedChanges.List(ctx, network, 10, 0)
+	s.Require().NoError(err)
+	s.Require().Len(items, 0)
+}
+
+func (s *ModuleTestSuite) TestSyncUnknownStatus() {
+	changes := celestials.Changes{
+		Head: 5,
+		Changes: []celestials.Change{
+			{
+				CelestialID: "expired",
+				Address:     "address",
+				ChangeID:    4,
+				Status:      "EXPIRED",
+			}, {
+				CelestialID: "success",
+				Address:     "address",
+				ChangeID:    5,
+				Status:      "VERIFIED",
+			},
+		},
+	}
+	failedChanges := pg.NewCelestialFailedChanges(s.storage.Connection())
+
+	for _, tt := range []struct {
+		name    string
+		opts    []ModuleOption
+		wantErr bool
+		saved   bool
+	}{
+		{
+			name:    "fail",
+			wantErr: true,
+		}, {
+			name: "quarantine",
+			opts: []ModuleOption{WithUnknownStatusPolicy(UnknownStatusQuarantine)},
+		}, {
+			name:  "fallback",
+			opts:  []ModuleOption{WithFallbackStatus(storage.StatusNOTVERIFIED)},
+			saved: true,
+		},
+	} {
+		s.Run(tt.name, func() {
+			s.loadFixtures()
+
+			s.api.EXPECT().
+				Changes(gomock.Any(), network, gomock.Any()).
+				Times(1).
+				Return(changes, nil)
+
+			ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer ctxCancel()
+
+			opts := append([]ModuleOption{WithLimit(10)}, tt.opts...)
+			m := NewWithAPI(
+				s.api,
+				func(ctx context.Context, address string) (uint64, error) {
+					return 1, nil
+				},
+				s.celestials,
+				s.celestialState,
+				s.storage.Transactable,
+				testIndexerName,
+				network,
+				opts...,
+			)
+			s.Require().NoError(m.getState(ctx, m.networks[0]))
+
+			err := m.sync(ctx, m.networks[0])
+			if tt.wantErr {
+				s.Require().Error(err)
+				s.Require().EqualValues(0, m.Quarantined())
+				return
+			}
+			s.Require().NoError(err)
+			s.Require().EqualValues(1, m.Quarantined())
+
+			st, err := s.celestialState.ByName(ctx, testIndexerName, network)
+			s.Require().NoError(err)
+			s.Require().EqualValues(5, st.ChangeId)
+
+			_, err = s.celestials.ById(ctx, network, "success")
+			s.Require().NoError(err)
+
+			item, err := s.celestials.ById(ctx, network, "expired")
+			if tt.saved {
+				s.Require().NoError(err)
+				s.Require().EqualValues(storage.StatusNOTVERIFIED, item.Status)
+			} else {
+				s.Require().ErrorIs(err, sql.ErrNoRows)
+			}
+
+			items, err := failedChanges.List(ctx, network, 10, 0)
+			s.Require().NoError(err)
+			s.Require().Len(items, 1)
+			s.Require().EqualValues(4, items[0].ChangeId)
+			s.Require().EqualValues("EXPIRED", items[0].Status)
+
+			tx, err := pg.BeginCelestialTransaction(ctx, s.storage.Transactable)
+			s.Require().NoError(err)
+			s.Require().NoError(tx.DeleteFailedChanges(ctx, network, 4))
+			s.Require().NoError(tx.Flush(ctx))
+			s.Require().NoError(tx.Close(ctx))
+		})
+	}
+}
+
+func (s *ModuleTestSuite) TestSyncOutput() {
+	s.loadFixtures()
+
+	s.api.EXPECT().
+		Changes(gomock.Any(), network, gomock.Any()).
+		Times(1).
+		Return(celestials.Changes{
+			Head: 5,
+			Changes: []celestials.Change{
+				{
+					CelestialID: "name 3",
+					Address:     "address",
+					ChangeID:    4,
+					Status:      "VERIFIED",
+				}, {
+					CelestialID: "name 3",
+					Address:     "address",
+					ChangeID:    5,
+					Status:      "PRIMARY",
+				}, {
+					CelestialID: "new name",
+					Address:     "address",
+					ChangeID:    6,
+					Status:      "VERIFIED",
+				},
+			},
+		}, nil)
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	m := NewWithAPI(
+		s.api,
+		func(ctx context.Context, address string) (uint64, error) {
+			return 1, nil
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithLimit(10),
+	)
+
+	input := modules.NewInput("input")
+	m.MustOutput(ChangesOutput).Attach(input)
+
+	s.Require().NoError(m.getState(ctx, m.networks[0]))
+	s.Require().NoError(m.sync(ctx, m.networks[0]))
+
+	want := []ChangeMessage{
+		{
+			Network:       network,
+			CelestialId:   "name 3",
+			ChangeId:      4,
+			PrevAddressId: 2,
+			PrevStatus:    storage.StatusPRIMARY,
+			AddressId:     1,
+			Status:        storage.StatusVERIFIED,
+		}, {
+			Network:       network,
+			CelestialId:   "name 3",
+			ChangeId:      5,
+			PrevAddressId: 1,
+			PrevStatus:    storage.StatusVERIFIED,
+			AddressId:     1,
+			Status:        storage.StatusPRIMARY,
+		}, {
+			Network:     network,
+			CelestialId: "new name",
+			ChangeId:    6,
+			IsNew:       true,
+			AddressId:   1,
+			Status:      storage.StatusVERIFIED,
+		}, {
+			Network:       network,
+			CelestialId:   "name 1",
+			ChangeId:      1,
+			PrevAddressId: 1,
+			PrevStatus:    storage.StatusPRIMARY,
+			AddressId:     1,
+			Status:        storage.StatusVERIFIED,
+			Revoked:       true,
+		},
+	}
+
+	for i := range want {
+		select {
+		case msg := <-input.Listen():
+			s.Require().Equal(want[i], msg)
+		case <-ctx.Done():
+			s.FailNow("message was not received")
+		}
+	}
+}
+
+func (s *ModuleTestSuite) TestPublishCancelled() {
+	m := NewWithAPI(
+		s.api,
+		func(ctx context.Context, address string) (uint64, error) {
+			return 1, nil
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+	)
+
+	input := modules.NewInputWithCapacity("input", 1)
+	m.MustOutput(ChangesOutput).Attach(input)
+
+	b := newBatch(network)
+	b.messages = []ChangeMessage{
+		{Network: network, CelestialId: "first", ChangeId: 1},
+		{Network: network, CelestialId: "second", ChangeId: 2},
+	}
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer ctxCancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.publish(ctx, b)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		s.FailNow("publish is blocked by full input")
+	}
+
+	msg := <-input.Listen()
+	s.Require().Equal("first", msg.(ChangeMessage).CelestialId)
+	s.Require().Empty(input.Listen())
+
+	m.MustOutput(ChangesOutput).Attach(modules.NewInputWithCapacity("unbuffered", 0))
+	s.Require().Panics(func() { m.Start(ctx) })
+}
+
+func (s *ModuleTestSuite) TestHealth() {
+	s.api.EXPECT().
+		Changes(gomock.Any(), network, gomock.Any()).
+		Times(1).
+		Return(celestials.Changes{}, errors.New("api error"))
+	s.api.EXPECT().
+		Changes(gomock.Any(), network, gomock.Any()).
+		Times(1).
+		Return(celestials.Changes{Head: 100}, nil)
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	m := NewWithAPI(
+		s.api,
+		func(ctx context.Context, address string) (uint64, error) {
+			return 1, nil
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithHealthThresholds(10, time.Minute),
+	)
+	n := m.networks[0]
+	n.state.ChangeId = 5
+	n.health.setChangeId(5)
+	n.health.startTime = time.Now().Add(-time.Minute * 2)
+
+	s.Require().Error(m.runSync(ctx, m.networks[0]))
+
+	health := m.Health()
+	s.Require().Equal(HealthStatusStalled, health.Status)
+	s.Require().Equal(1, health.ConsecutiveFailures)
+	s.Require().Contains(health.LastError, "api error")
+	s.Require().EqualValues(100, health.Head)
+	s.Require().EqualValues(5, health.ChangeId)
+	s.Require().EqualValues(95, health.Lag)
+	s.Require().True(health.LastSyncTime.IsZero())
+
+	recorder := httptest.NewRecorder()
+	m.HealthHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+	s.Require().Equal(http.StatusServiceUnavailable, recorder.Code)
+
+	n.health.startTime = time.Now()
+	s.Require().Equal(HealthStatusLagging, m.Health().Status)
+
+	n.health.setChangeId(95)
+	n.health.setSyncResult(nil)
+	health = m.Health()
+	s.Require().Equal(HealthStatusHealthy, health.Status)
+	s.Require().Equal(0, health.ConsecutiveFailures)
+	s.Require().Empty(health.LastError)
+	s.Require().False(health.LastSyncTime.IsZero())
+
+	recorder = httptest.NewRecorder()
+	m.HealthHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+	s.Require().Equal(http.StatusOK, recorder.Code)
+
+	var response Health
+	s.Require().NoError(json.NewDecoder(recorder.Body).Decode(&response))
+	s.Require().Equal(HealthStatusHealthy, response.Status)
+	s.Require().EqualValues(95, response.ChangeId)
+	s.Require().EqualValues(100, response.Head)
+}
+
+func (s *ModuleTestSuite) TestLeaderElection() {
+	s.loadFixtures()
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	api := celestialsMock.NewMockAPI(ctrl)
+	api.EXPECT().
+		Changes(gomock.Any(), network, gomock.Any()).
+		AnyTimes().
+		Return(celestials.Changes{Head: 3}, nil)
+
+	lock := storageMock.NewMockILeaderLock(ctrl)
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	m := NewWithAPI(
+		api,
+		func(ctx context.Context, address string) (uint64, error) {
+			return 1, nil
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithLeaderElection(lock, time.Millisecond*10),
+	)
+	s.Require().False(m.IsLeader())
+	health := m.Health()
+	s.Require().Equal(HealthStatusStandby, health.Status)
+	s.Require().Equal(network, health.Network)
+	s.Require().ErrorIs(m.SyncNow(ctx), ErrNotLeader)
+	s.Require().ErrorIs(m.WaitSync(ctx), ErrNotLeader)
+
+	lock.EXPECT().TryLock(gomock.Any()).Return(false, nil).Times(1)
+	stop, err := m.lead(ctx)
+	s.Require().NoError(err)
+	s.Require().Nil(stop)
+	s.Require().False(m.IsLeader())
+
+	lock.EXPECT().TryLock(gomock.Any()).Return(true, nil).Times(1)
+	stop, err = m.lead(ctx)
+	s.Require().NoError(err)
+	s.Require().NotNil(stop)
+	s.Require().True(m.IsLeader())
+	s.Require().NotEqual(HealthStatusStandby, m.Health().Status)
+	s.Require().EqualValues(3, m.networks[0].state.ChangeId)
+
+	lock.EXPECT().Unlock(gomock.Any()).Return(nil).Times(1)
+	m.resign(stop)
+	s.Require().False(m.IsLeader())
+}
+
+func (s *ModuleTestSuite) TestSyncControl() {
+	s.loadFixtures()
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	api := celestialsMock.NewMockAPI(ctrl)
+	api.EXPECT().
+		Changes(gomock.Any(), network, gomock.Any()).
+		AnyTimes().
+		Return(celestials.Changes{Head: 3}, nil)
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	m := NewWithAPI(
+		api,
+		func(ctx context.Context, address string) (uint64, error) {
+			return 1, nil
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithIndexPeriod(time.Hour),
+		WithRetryPeriod(time.Hour),
+	)
+
+	syncCtx, syncCancel := context.WithCancel(ctx)
+	m.Start(syncCtx)
+
+	s.Require().NoError(m.SyncNow(ctx))
+	s.Require().Equal(HealthStatusHealthy, m.Health().Status)
+
+	waiters, err := m.addWaiters()
+	s.Require().NoError(err)
+	s.Require().Len(waiters, 1)
+
+	m.Pause()
+	s.Require().ErrorIs(m.wait(ctx, waiters), ErrPaused)
+	s.Require().True(m.Paused())
+	health := m.Health()
+	s.Require().Equal(HealthStatusPaused, health.Status)
+	s.Require().EqualValues(3, health.Head)
+	s.Require().Equal(network, health.Network)
+
+	s.Require().ErrorIs(m.SyncNow(ctx), ErrPaused)
+
+	m.Resume()
+	s.Require().False(m.Paused())
+	s.Require().NoError(m.SyncNow(ctx))
+
+	syncCancel()
+	s.Require().NoError(m.Close())
+}
+
+func (s *ModuleTestSuite) TestAdaptivePolling() {
+	m := NewWithAPI(
+		s.api,
+		func(ctx context.Context, address string) (uint64, error) {
+			return 1, nil
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithIndexPeriod(time.Minute),
+	)
+	n := m.networks[0]
+	s.Require().Equal(time.Minute, m.nextSyncDelay(n, nil, true))
+	s.Require().Equal(3*time.Minute, m.stallTimeout())
+
+	WithAdaptivePolling(time.Second, time.Second*10)(m)
+	s.Require().Equal(30*time.Second, m.stallTimeout())
+
+	n.health.setHead(10)
+	n.health.setChangeId(5)
+	s.Require().Equal(time.Duration(0), m.nextSyncDelay(n, nil, true))
+
+	n.health.setChangeId(10)
+	s.Require().Equal(time.Second, m.nextSyncDelay(n, nil, true))
+
+	for _, want := range []time.Duration{2, 4, 8, 10, 10} {
+		s.Require().Equal(want*time.Second, m.nextSyncDelay(n, nil, false))
+	}
+	s.Require().Equal(time.Second, m.nextSyncDelay(n, nil, true))
+	s.Require().Equal(2*time.Second, m.nextSyncDelay(n, errors.New("api error"), false))
+	s.Require().Equal(4*time.Second, m.nextSyncDelay(n, errors.New("api error"), true))
+}
+
+func (s *ModuleTestSuite) TestResync() {
+	s.loadFixtures()
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	m := NewWithAPI(
+		s.api,
+		func(ctx context.Context, address string) (uint64, error) {
+			switch address {
+			case "address 1":
+				return 1, nil
+			default:
+				return 2, nil
+			}
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithLimit(10),
+	)
+	n := m.networks[0]
+	s.Require().NoError(m.getState(ctx, n))
+	s.Require().EqualValues(3, n.state.ChangeId)
+
+	input := modules.NewInput("input")
+	m.MustOutput(ChangesOutput).Attach(input)
+
+	s.Require().Error(m.Resync(ctx, "unknown"))
+	s.Require().NoError(m.Resync(ctx))
+	s.Require().Error(m.Resync(ctx, network))
+
+	progress := m.ResyncProgress()
+	s.Require().Len(progress, 1)
+	s.Require().Equal(network, progress[0].Network)
+	s.Require().EqualValues(0, progress[0].ChangeId)
+	s.Require().NotNil(m.Health().Resync)
+
+	// live table keeps serving reads during resync
+	_, err := s.celestials.ById(ctx, network, "travel 1")
+	s.Require().NoError(err)
+
+	s.api.EXPECT().
+		Changes(gomock.Any(), network, gomock.Any()).
+		Times(1).
+		Return(celestials.Changes{
+			Head: 3,
+			Changes: []celestials.Change{
+				{CelestialID: "name 1", Address: "address 1", ChangeID: 1, Status: "PRIMARY"},
+				{CelestialID: "name 2", Address: "address 1", ChangeID: 2, Status: "VERIFIED"},
+				{CelestialID: "resync", Address: "address 2", ChangeID: 3, Status: "PRIMARY"},
+			},
+		}, nil)
+
+	s.Require().NoError(m.resyncStep(ctx, n, n.resync.Load()))
+	s.Require().Nil(n.resync.Load())
+	s.Require().Empty(m.ResyncProgress())
+
+	item, err := s.celestials.ById(ctx, network, "resync")
+	s.Require().NoError(err)
+	s.Require().EqualValues(2, item.AddressId)
+	s.Require().EqualValues(3, item.ChangeId)
+	s.Require().Equal(storage.StatusPRIMARY, item.Status)
+
+	_, err = s.celestials.ById(ctx, network, "travel 1")
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+
+	st, err := s.celestialState.ByName(ctx, testIndexerName, network)
+	s.Require().NoError(err)
+	s.Require().EqualValues(3, st.ChangeId)
+
+	_, err = s.celestialState.ByName(ctx, testIndexerName+resyncStateSuffix, network)
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+
+	expected := []struct {
+		id      string
+		isNew   bool
+		removed bool
+	}{
+		{"name 3", false, true},
+		{"resync", true, false},
+		{"travel 1", false, true},
+		{"travel 2", false, true},
+		{"travel 3", false, true},
+	}
+	s.Require().Len(input.Listen(), len(expected))
+	for _, e := range expected {
+		msg := (<-input.Listen()).(ChangeMessage)
+		s.Require().Equal(e.id, msg.CelestialId)
+		s.Require().True(msg.Resynced, e.id)
+		s.Require().Equal(e.isNew, msg.IsNew, e.id)
+		s.Require().Equal(e.removed, msg.Removed, e.id)
+	}
+
+	history, err := pg.NewCelestialHistory(s.storage.Connection()).ByAddressId(ctx, network, 20, 0, 10)
+	s.Require().NoError(err)
+	s.Require().Empty(history)
+
+	history, err = pg.NewCelestialHistory(s.storage.Connection()).ById(ctx, network, "resync", 0, 10)
+	s.Require().NoError(err)
+	s.Require().Len(history, 1)
+	s.Require().EqualValues(3, history[0].ChangeId)
+}
+
+func (s *ModuleTestSuite) TestRollback() {
+	s.loadFixtures()
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	api := celestialsMock.NewMockAPI(ctrl)
+	api.EXPECT().
+		Changes(gomock.Any(), network, gomock.Any()).
+		AnyTimes().
+		Return(celestials.Changes{Head: 3}, nil)
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	m := NewWithAPI(
+		api,
+		func(ctx context.Context, address string) (uint64, error) {
+			return 1, nil
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithIndexPeriod(time.Hour),
+		WithRetryPeriod(time.Hour),
+	)
+
+	input := modules.NewInput("input")
+	m.MustOutput(ChangesOutput).Attach(input)
+
+	syncCtx, syncCancel := context.WithCancel(ctx)
+	m.Start(syncCtx)
+
+	s.Require().Error(m.Rollback(ctx, "unknown", 2))
+	s.Require().Error(m.Rollback(ctx, network, 5))
+	s.Require().Error(m.Rollback(ctx, network, 0))
+	s.Require().NoError(m.Rollback(ctx, network, 2))
+
+	removed := []string{"name 3", "travel 1", "travel 2", "travel 3"}
+	for i := range removed {
+		select {
+		case msg := <-input.Listen():
+			change := msg.(ChangeMessage)
+			s.Require().Equal(removed[i], change.CelestialId)
+			s.Require().True(change.RolledBack)
+			s.Require().True(change.Removed)
+		case <-ctx.Done():
+			s.FailNow("message was not received")
+		}
+	}
+	s.Require().Empty(input.Listen())
+
+	st, err := s.celestialState.ByName(ctx, testIndexerName, network)
+	s.Require().NoError(err)
+	s.Require().EqualValues(2, st.ChangeId)
+	s.Require().EqualValues(2, m.Health().ChangeId)
+
+	_, err = s.celestials.ById(ctx, network, "name 3")
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+	_, err = s.celestials.ById(ctx, network, "travel 1")
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+
+	item, err := s.celestials.ById(ctx, network, "name 1")
+	s.Require().NoError(err)
+	s.Require().Equal(storage.StatusPRIMARY, item.Status)
+
+	history, err := pg.NewCelestialHistory(s.storage.Connection()).ByAddressId(ctx, network, 20, 0, 10)
+	s.Require().NoError(err)
+	s.Require().Empty(history)
+
+	syncCancel()
+	s.Require().NoError(m.Close())
+}
+
+func (s *ModuleTestSuite) TestAudit() {
+	s.loadFixtures()
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	m := NewWithAPI(
+		s.api,
+		func(ctx context.Context, address string) (uint64, error) {
+			switch address {
+			case "address 1":
+				return 1, nil
+			default:
+				return 2, nil
+			}
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithLimit(10),
+	)
+
+	s.api.EXPECT().
+		Changes(gomock.Any(), network, gomock.Any()).
+		Times(1).
+		Return(celestials.Changes{
+			Head: 4,
+			Changes: []celestials.Change{
+				{CelestialID: "name 1", Address: "address 1", ChangeID: 1, Status: "PRIMARY"},
+				{CelestialID: "name 2", Address: "address 1", ChangeID: 2, Status: "VERIFIED", ImageURL: "image_url"},
+				{CelestialID: "missing", Address: "address 2", ChangeID: 3, Status: "PRIMARY"},
+				{CelestialID: "not audited", Address: "address 2", ChangeID: 4, Status: "PRIMARY"},
+			},
+		}, nil)
+
+	_, err := m.Audit(ctx, "unknown")
+	s.Require().Error(err)
+
+	// repair is executed by sync goroutine which is replaced by the test
+	n := m.networks[0]
+	go func() {
+		req := <-n.repair
+		req.result <- m.applyRepair(ctx, n, req.celestials)
+	}()
+
+	report, err := m.Audit(ctx, network, WithAuditRepair())
+	s.Require().NoError(err)
+	s.Require().False(report.Consistent())
+	s.Require().EqualValues(3, report.ChangeId)
+	s.Require().Equal(4, report.Checked)
+	s.Require().Zero(report.Skipped)
+
+	s.Require().Len(report.Missing, 1)
+	s.Require().Equal("missing", report.Missing[0].Id)
+	s.Require().EqualValues(2, report.Missing[0].Expected.AddressId)
+
+	s.Require().Len(report.Stale, 1)
+	s.Require().Equal("name 2", report.Stale[0].Id)
+	s.Require().Equal("image_url", report.Stale[0].Expected.ImageUrl)
+	s.Require().Empty(report.Stale[0].Actual.ImageUrl)
+
+	s.Require().Len(report.Extra, 1)
+	s.Require().Equal("name 3", report.Extra[0].Id)
+	s.Require().Equal(2, report.Repaired)
+
+	item, err := s.celestials.ById(ctx, network, "missing")
+	s.Require().NoError(err)
+	s.Require().Equal(storage.StatusPRIMARY, item.Status)
+
+	item, err = s.celestials.ById(ctx, network, "name 2")
+	s.Require().NoError(err)
+	s.Require().Equal("image_url", item.ImageUrl)
+
+	_, err = s.celestials.ById(ctx, network, "not audited")
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+
+	history, err := pg.NewCelestialHistory(s.storage.Connection()).ById(ctx, network, "missing", 0, 10)
+	s.Require().NoError(err)
+	s.Require().Empty(history)
+}
+
+func (s *ModuleTestSuite) TestSyncPrimaryInBatch() {
+	s.loadFixtures()
+
+	b := newBatch(network)
+	b.apply(storage.Celestial{Id: "newer", AddressId: 1, ChangeId: 5, Status: storage.StatusPRIMARY})
+	b.apply(storage.Celestial{Id: "older", AddressId: 1, ChangeId: 4, Status: storage.StatusPRIMARY})
+	s.Require().Equal(storage.StatusPRIMARY, b.celestials["newer"].Status)
+	s.Require().Equal(storage.StatusVERIFIED, b.celestials["older"].Status)
+
+	s.api.EXPECT().
+		Changes(gomock.Any(), network, gomock.Any()).
+		Times(1).
+		Return(celestials.Changes{
+			Head: 5,
+			Changes: []celestials.Change{
+				{CelestialID: "dup 1", Address: "address 1", ChangeID: 4, Status: "PRIMARY"},
+				{CelestialID: "dup 2", Address: "address 1", ChangeID: 5, Status: "PRIMARY"},
+			},
+		}, nil)
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	m := NewWithAPI(
+		s.api,
+		func(ctx context.Context, address string) (uint64, error) {
+			return 1, nil
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithLimit(10),
+	)
+	s.Require().NoError(m.getState(ctx, m.networks[0]))
+	s.Require().NoError(m.sync(ctx, m.networks[0]))
+
+	primary, err := s.celestials.Primary(ctx, network, 1)
+	s.Require().NoError(err)
+	s.Require().Equal("dup 2", primary.Id)
+
+	items, _, err := s.celestials.ByAddressId(ctx, network, 1, storage.ListOptions{Limit: 10})
+	s.Require().NoError(err)
+	for i := range items {
+		if items[i].Id != "dup 2" {
+			s.Require().Equal(storage.StatusVERIFIED, items[i].Status, items[i].Id)
+		}
+	}
+}
+
+func (s *ModuleTestSuite) TestSyncPrimarySwap() {
+	s.loadFixtures()
+
+	s.api.EXPECT().
+		Changes(gomock.Any(), network, gomock.Any()).
+		Times(1).
+		Return(celestials.Changes{
+			Head: 5,
+			Changes: []celestials.Change{
+				{CelestialID: "name 1", Address: "address 5", ChangeID: 4, Status: "PRIMARY"},
+				{CelestialID: "name 2", Address: "address 1", ChangeID: 5, Status: "PRIMARY"},
+			},
+		}, nil)
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	m := NewWithAPI(
+		s.api,
+		func(ctx context.Context, address string) (uint64, error) {
+			if address == "address 5" {
+				return 5, nil
+			}
+			return 1, nil
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithLimit(10),
+	)
+	s.Require().NoError(m.getState(ctx, m.networks[0]))
+	s.Require().NoError(m.sync(ctx, m.networks[0]))
+
+	primary, err := s.celestials.Primary(ctx, network, 1)
+	s.Require().NoError(err)
+	s.Require().Equal("name 2", primary.Id)
+
+	primary, err = s.celestials.Primary(ctx, network, 5)
+	s.Require().NoError(err)
+	s.Require().Equal("name 1", primary.Id)
+
+	st, err := s.celestialState.ByName(ctx, testIndexerName, network)
+	s.Require().NoError(err)
+	s.Require().EqualValues(5, st.ChangeId)
+}
+
+func (s *ModuleTestSuite) TestSyncKeepsTimestamps() {
+	s.loadFixtures()
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	before, err := s.celestials.ById(ctx, network, "name 1")
+	s.Require().NoError(err)
+	s.Require().Equal(storage.StatusPRIMARY, before.Status)
+
+	s.api.EXPECT().
+		Changes(gomock.Any(), network, gomock.Any()).
+		Times(1).
+		Return(celestials.Changes{
+			Head: 5,
+			Changes: []celestials.Change{
+				{CelestialID: "name 1", Address: "address 1", ImageURL: "image", ChangeID: 4, Status: "PRIMARY"},
+				{CelestialID: "name 2", Address: "address 1", ChangeID: 5, Status: "VERIFIED"},
+			},
+		}, nil)
+
+	m := NewWithAPI(
+		s.api,
+		func(ctx context.Context, address string) (uint64, error) {
+			return 1, nil
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithLimit(10),
+	)
+	s.Require().NoError(m.getState(ctx, m.networks[0]))
+	s.Require().NoError(m.sync(ctx, m.networks[0]))
+
+	after, err := s.celestials.ById(ctx, network, "name 1")
+	s.Require().NoError(err)
+	s.Require().Equal("image", after.ImageUrl)
+	s.Require().Equal(storage.StatusPRIMARY, after.Status)
+	s.Require().Equal(before.CreatedAt, after.CreatedAt)
+	s.Require().Equal(before.StatusChangedAt, after.StatusChangedAt)
+	s.Require().True(after.UpdatedAt.After(before.UpdatedAt))
+}
+
+func (s *ModuleTestSuite) TestSyncInvalidatesCache() {
+	s.loadFixtures()
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	cached := cache.NewCelestials(s.celestials, 100, time.Hour)
+
+	m := NewWithAPI(
+		s.api,
+		func(ctx context.Context, address string) (uint64, error) {
+			switch address {
+			case "address 1":
+				return 1, nil
+			default:
+				return 5, nil
+			}
+		},
+		cached,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithLimit(10),
+		WithCacheInvalidator(cached),
+	)
+	s.Require().NoError(m.getState(ctx, m.networks[0]))
+
+	primary, err := cached.Primary(ctx, network, 1)
+	s.Require().NoError(err)
+	s.Require().Equal("name 1", primary.Id)
+
+	revoked, err := cached.ById(ctx, network, "name 1")
+	s.Require().NoError(err)
+	s.Require().Equal(storage.StatusPRIMARY, revoked.Status)
+
+	_, err = cached.Primary(ctx, network, 5)
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+
+	moved, err := cached.ById(ctx, network, "name 3")
+	s.Require().NoError(err)
+	s.Require().EqualValues(2, moved.AddressId)
+
+	primary, err = cached.Primary(ctx, network, 2)
+	s.Require().NoError(err)
+	s.Require().Equal("name 3", primary.Id)
+
+	s.api.EXPECT().
+		Changes(gomock.Any(), network, gomock.Any()).
+		Times(1).
+		Return(celestials.Changes{
+			Head: 5,
+			Changes: []celestials.Change{
+				{CelestialID: "cached", Address: "address 1", ChangeID: 4, Status: "PRIMARY"},
+				{CelestialID: "name 3", Address: "address 5", ChangeID: 5, Status: "PRIMARY"},
+			},
+		}, nil)
+	s.Require().NoError(m.sync(ctx, m.networks[0]))
+
+	primary, err = cached.Primary(ctx, network, 1)
+	s.Require().NoError(err)
+	s.Require().Equal("cached", primary.Id)
+
+	primary, err = cached.Primary(ctx, network, 5)
+	s.Require().NoError(err)
+	s.Require().Equal("name 3", primary.Id)
+
+	_, err = cached.Primary(ctx, network, 2)
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+
+	moved, err = cached.ById(ctx, network, "name 3")
+	s.Require().NoError(err)
+	s.Require().EqualValues(5, moved.AddressId)
+
+	revoked, err = cached.ById(ctx, network, "name 1")
+	s.Require().NoError(err)
+	s.Require().Equal(storage.StatusVERIFIED, revoked.Status)
+}
+
+func (s *ModuleTestSuite) TestNewWithAPI() {
+	m := NewWithAPI(
+		s.api,
+		func(ctx context.Context, address string) (uint64, error) {
+			return 1, nil
+		},
+		s.celestials,
+		s.celestialState,
+		s.storage.Transactable,
+		testIndexerName,
+		network,
+		WithRequestTimeout(time.Second),
+	)
+	s.Require().Equal(s.api, m.celestialsApi)
+	s.Require().Equal(time.Second, m.requestTimeout)
+	s.Require().EqualValues(100, m.limit)
 }
 
 func TestSuiteModule_Run(t *testing.T) {
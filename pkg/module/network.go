@@ -0,0 +1,82 @@
+package module
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+// networkSync - sync state of one network (chain id). Every network is indexed by its own goroutine.
+type networkSync struct {
+	name           string
+	addressHandler AddressHandler
+	state          storage.CelestialState
+	health         healthState
+
+	// delay - current delay between syncs in adaptive polling mode
+	delay time.Duration
+
+	// trigger - requests of immediate sync. Buffer of one element coalesces concurrent requests.
+	trigger chan struct{}
+
+	waitersMx sync.Mutex
+	waiters   []chan error
+
+	// resync - running replay of changes to shadow table or nil
+	resync atomic.Pointer[resync]
+
+	// rollback - requests of rollback which are executed by sync goroutine between syncs
+	rollback chan rollbackRequest
+	// repair - requests of audit repair which are executed by sync goroutine between syncs
+	repair chan repairRequest
+}
+
+func newNetworkSync(name string, addressHandler AddressHandler) *networkSync {
+	return &networkSync{
+		name:           name,
+		addressHandler: addressHandler,
+		trigger:        make(chan struct{}, 1),
+		rollback:       make(chan rollbackRequest),
+		repair:         make(chan repairRequest),
+	}
+}
+
+// Networks - returns names of indexed networks
+func (m *Module) Networks() []string {
+	names := make([]string, len(m.networks))
+	for i := range m.networks {
+		names[i] = m.networks[i].name
+	}
+	return names
+}
+
+// network - returns sync state of network by its name
+func (m *Module) network(name string) (*networkSync, error) {
+	for i := range m.networks {
+		if m.networks[i].name == name {
+			return m.networks[i], nil
+		}
+	}
+	return nil, errors.Errorf("unknown network: %s", name)
+}
+
+func (m *Module) addNetwork(name string, addressHandler AddressHandler) {
+	for i := range m.networks {
+		if m.networks[i].name == name {
+			m.networks[i].addressHandler = addressHandler
+			return
+		}
+	}
+	m.networks = append(m.networks, newNetworkSync(name, addressHandler))
+}
+
+// handler - returns address handler of network or the default handler of module
+func (m *Module) handler(n *networkSync) AddressHandler {
+	if n.addressHandler != nil {
+		return n.addressHandler
+	}
+	return m.addressHandler
+}
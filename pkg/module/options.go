@@ -1,6 +1,12 @@
 package module
 
-import "time"
+import (
+	"time"
+
+	celestials "github.com/celenium-io/celestial-module/pkg/api"
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/prometheus/client_golang/prometheus"
+)
 
 type ModuleOption func(*Module)
 
@@ -10,6 +16,17 @@ func WithIndexPeriod(period time.Duration) ModuleOption {
 	}
 }
 
+// WithAdaptivePolling - enables adaptive scheduling of sync instead of fixed index period. Module polls API immediately
+// while it's behind API head, and backs off from min to max period while API is idle or requests fail.
+func WithAdaptivePolling(minPeriod, maxPeriod time.Duration) ModuleOption {
+	return func(m *Module) {
+		if minPeriod > 0 && maxPeriod >= minPeriod {
+			m.minIndexPeriod = minPeriod
+			m.maxIndexPeriod = maxPeriod
+		}
+	}
+}
+
 func WithDatabaseTimeout(timeout time.Duration) ModuleOption {
 	return func(m *Module) {
 		m.databaseTimeout = timeout
@@ -21,3 +38,121 @@ func WithLimit(limit int64) ModuleOption {
 		m.limit = limit
 	}
 }
+
+// WithPrefetch - sets count of pages which are received from API in advance while the current page is processed (default: 1)
+func WithPrefetch(pages int) ModuleOption {
+	return func(m *Module) {
+		if pages >= 0 {
+			m.prefetch = pages
+		}
+	}
+}
+
+// WithAddressWorkers - sets count of concurrent address handler calls (default: 1). Address handler should be safe
+// for concurrent use if count is greater than 1.
+func WithAddressWorkers(count int) ModuleOption {
+	return func(m *Module) {
+		if count > 0 {
+			m.addressWorkers = count
+		}
+	}
+}
+
+func WithRequestTimeout(timeout time.Duration) ModuleOption {
+	return func(m *Module) {
+		if timeout > 0 {
+			m.requestTimeout = timeout
+		}
+	}
+}
+
+func WithAPI(api celestials.API) ModuleOption {
+	return func(m *Module) {
+		if api != nil {
+			m.celestialsApi = api
+		}
+	}
+}
+
+func WithRetryPeriod(period time.Duration) ModuleOption {
+	return func(m *Module) {
+		if period > 0 {
+			m.retryPeriod = period
+		}
+	}
+}
+
+func WithUnknownStatusPolicy(policy UnknownStatusPolicy) ModuleOption {
+	return func(m *Module) {
+		m.unknownStatusPolicy = policy
+	}
+}
+
+// WithFallbackStatus - sets status which is used for changes with unknown status and switches policy to UnknownStatusFallback
+func WithFallbackStatus(status storage.Status) ModuleOption {
+	return func(m *Module) {
+		if status.IsValid() {
+			m.unknownStatusPolicy = UnknownStatusFallback
+			m.fallbackStatus = status
+		}
+	}
+}
+
+// WithMetrics - enables prometheus metrics of module. If module is created by New, metrics of Celestials API client are registered too.
+func WithMetrics(reg prometheus.Registerer) ModuleOption {
+	return func(m *Module) {
+		if reg != nil {
+			m.metrics = newMetrics(reg, m.indexerName)
+		}
+	}
+}
+
+// WithHealthThresholds - sets max difference between API head and applied change id after which module is lagging
+// and duration without successful sync after which module is stalled (default: 3 index periods or 3 max periods of adaptive polling)
+func WithHealthThresholds(maxLag int64, stallTimeout time.Duration) ModuleOption {
+	return func(m *Module) {
+		if maxLag >= 0 {
+			m.maxLag = maxLag
+		}
+		if stallTimeout > 0 {
+			m.healthStallTimeout = stallTimeout
+		}
+	}
+}
+
+// WithNetwork - adds network (chain id) which is indexed by module in its own sync loop.
+// If address handler is nil, the handler passed to constructor is used.
+func WithNetwork(network string, addressHandler AddressHandler) ModuleOption {
+	return func(m *Module) {
+		if network != "" {
+			m.addNetwork(network, addressHandler)
+		}
+	}
+}
+
+// WithLeaderElection - enables coordination of module replicas. Only the instance which holds the lock syncs changes,
+// other instances try to acquire the lock every check period and take over when the leader's lock is released.
+func WithLeaderElection(lock storage.ILeaderLock, checkPeriod time.Duration) ModuleOption {
+	return func(m *Module) {
+		m.leaderLock = lock
+		if checkPeriod > 0 {
+			m.leaderCheckPeriod = checkPeriod
+		}
+	}
+}
+
+// WithBatchAddressHandler - sets handler which resolves all addresses of a page in one call. Addresses which are not
+// resolved by it are passed to the single-address handler. It's used for networks without own address handler.
+func WithBatchAddressHandler(handler BatchAddressHandler) ModuleOption {
+	return func(m *Module) {
+		m.batchAddressHandler = handler
+	}
+}
+
+// WithCacheInvalidator - sets cache of celestial ids (for example, cache.Celestials) which is invalidated
+// after module commits changes
+func WithCacheInvalidator(cache CacheInvalidator) ModuleOption {
+	return func(m *Module) {
+		m.cache = cache
+	}
+}
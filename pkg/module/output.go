@@ -0,0 +1,209 @@
+package module
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/celenium-io/celestial-module/pkg/storage/postgres"
+	"github.com/dipdup-net/indexer-sdk/pkg/modules"
+	"github.com/pkg/errors"
+)
+
+// ChangesOutput - name of output which receives ChangeMessage for every applied change
+const ChangesOutput = "celestials.changes"
+
+// publishPollInterval - period of checking free space of full connected input
+const publishPollInterval = 10 * time.Millisecond
+
+// ChangeMessage - message about applied change of celestial id. It's pushed to ChangesOutput after transaction commit.
+type ChangeMessage struct {
+	Network     string
+	CelestialId string
+	ChangeId    int64
+	ImageUrl    string
+
+	// IsNew - true if celestial id was not indexed before the change
+	IsNew         bool
+	PrevAddressId uint64
+	PrevStatus    storage.Status
+	AddressId     uint64
+	Status        storage.Status
+
+	// Revoked - true if primary status was revoked because other celestial id became primary for the same address.
+	// ChangeId is the last change of revoked celestial id in this case.
+	Revoked bool
+	// RolledBack - true if message is produced by rollback. ChangeId is the change id of restored state.
+	RolledBack bool
+	// Resynced - true if message is produced by swap of tables at the end of resync
+	Resynced bool
+	// Removed - true if celestial id was removed by rollback or resync. New address and status are empty in this case.
+	Removed bool
+}
+
+// buildMessages - receives state of changed celestial ids before transaction and creates messages in order of applying
+func (b *batch) buildMessages(ctx context.Context, tx postgres.CelestialTransaction) error {
+	if len(b.history) == 0 {
+		return nil
+	}
+
+	current, err := tx.CelestialsByIds(ctx, b.network, maps.Keys(b.celestials))
+	if err != nil {
+		return errors.Wrap(err, "celestials by ids")
+	}
+
+	prev := make(map[string]storage.Celestial, len(current))
+	for i := range current {
+		prev[current[i].Id] = current[i]
+	}
+
+	b.messages = make([]ChangeMessage, len(b.history))
+	for i, h := range b.history {
+		b.messages[i] = ChangeMessage{
+			Network:     b.network,
+			CelestialId: h.CelestialId,
+			ChangeId:    h.ChangeId,
+			ImageUrl:    h.ImageUrl,
+			AddressId:   h.AddressId,
+			Status:      h.Status,
+		}
+		if p, ok := prev[h.CelestialId]; ok {
+			b.messages[i].PrevAddressId = p.AddressId
+			b.messages[i].PrevStatus = p.Status
+		} else {
+			b.messages[i].IsNew = true
+		}
+		prev[h.CelestialId] = storage.Celestial{
+			Id:        h.CelestialId,
+			Network:   h.Network,
+			AddressId: h.AddressId,
+			Status:    h.Status,
+		}
+	}
+	return nil
+}
+
+// stateMessages - creates messages about celestial ids replaced by rollback or resync ordered by celestial id.
+// Celestial ids which are absent in after are removed.
+func stateMessages(network string, before, after []storage.Celestial) []ChangeMessage {
+	prev := make(map[string]storage.Celestial, len(before))
+	for i := range before {
+		prev[before[i].Id] = before[i]
+	}
+	current := make(map[string]storage.Celestial, len(after))
+	for i := range after {
+		current[after[i].Id] = after[i]
+	}
+
+	ids := make([]string, 0, len(prev)+len(current))
+	for id := range prev {
+		ids = append(ids, id)
+	}
+	for id := range current {
+		if _, ok := prev[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, strings.Compare)
+
+	messages := make([]ChangeMessage, len(ids))
+	for i, id := range ids {
+		messages[i] = ChangeMessage{
+			Network:     network,
+			CelestialId: id,
+		}
+		if p, ok := prev[id]; ok {
+			messages[i].PrevAddressId = p.AddressId
+			messages[i].PrevStatus = p.Status
+		} else {
+			messages[i].IsNew = true
+		}
+		if cid, ok := current[id]; ok {
+			messages[i].ChangeId = cid.ChangeId
+			messages[i].ImageUrl = cid.ImageUrl
+			messages[i].AddressId = cid.AddressId
+			messages[i].Status = cid.Status
+		} else {
+			messages[i].Removed = true
+		}
+	}
+	return messages
+}
+
+// revoke - adds messages about celestial ids which lost primary status in batch transaction
+func (b *batch) revoke(revoked []storage.Celestial) {
+	for i := range revoked {
+		if _, ok := b.celestials[revoked[i].Id]; ok {
+			continue
+		}
+		b.revoked = append(b.revoked, revoked[i])
+		b.messages = append(b.messages, ChangeMessage{
+			Network:       b.network,
+			CelestialId:   revoked[i].Id,
+			ChangeId:      revoked[i].ChangeId,
+			ImageUrl:      revoked[i].ImageUrl,
+			PrevAddressId: revoked[i].AddressId,
+			PrevStatus:    storage.StatusPRIMARY,
+			AddressId:     revoked[i].AddressId,
+			Status:        revoked[i].Status,
+			Revoked:       true,
+		})
+	}
+}
+
+// publish - pushes messages of committed batch to ChangesOutput. It waits while connected inputs are full
+// and stops when context is done, so a stalled consumer does not block closing of the module. Sync goroutines
+// and retries of every network publish under one lock, so free space found by waitInput can't be taken
+// by other publisher before Push and batches are not interleaved.
+func (m *Module) publish(ctx context.Context, b *batch) {
+	if len(b.messages) == 0 {
+		return
+	}
+
+	m.publishMx.Lock()
+	defer m.publishMx.Unlock()
+
+	output := m.MustOutput(ChangesOutput)
+	for i := range b.messages {
+		for _, input := range output.ConnectedInputs() {
+			if !waitInput(ctx, input) {
+				m.Log.Warn().
+					Str("network", b.network).
+					Str("input", input.Name()).
+					Int("dropped", len(b.messages)-i).
+					Msg("publishing of change messages is cancelled")
+				return
+			}
+			input.Push(b.messages[i])
+		}
+	}
+}
+
+// waitInput - waits until input has free space. It returns false if context is done or input is unbuffered
+// because push to unbuffered input can't be cancelled.
+func waitInput(ctx context.Context, input *modules.Input) bool {
+	ch := input.Listen()
+	if cap(ch) == 0 {
+		return false
+	}
+	for len(ch) >= cap(ch) {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(publishPollInterval):
+		}
+	}
+	return ctx.Err() == nil
+}
+
+// checkInputs - panics if unbuffered input is connected to ChangesOutput
+func (m *Module) checkInputs() {
+	for _, input := range m.MustOutput(ChangesOutput).ConnectedInputs() {
+		if cap(input.Listen()) == 0 {
+			panic("unbuffered input " + input.Name() + " is connected to " + ChangesOutput)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package module
+
+import (
+	"context"
+
+	celestials "github.com/celenium-io/celestial-module/pkg/api"
+	"github.com/celenium-io/celestial-module/pkg/storage"
+)
+
+// page - result of request to Celestials API
+type page struct {
+	changes celestials.Changes
+	err     error
+}
+
+// fetch - receives pages of changes starting from the current state of network. At most prefetch pages are buffered
+// while the consumer processes the previous one. Channel is closed after the last page, error or context cancellation.
+func (m *Module) fetch(ctx context.Context, n *networkSync) <-chan page {
+	pages := make(chan page, m.prefetch)
+	fromChangeId := n.state.ChangeId
+
+	go func() {
+		defer close(pages)
+
+		for {
+			changes, err := m.getChanges(ctx, n, fromChangeId)
+			select {
+			case <-ctx.Done():
+				return
+			case pages <- page{changes: changes, err: err}:
+			}
+			if err != nil || len(changes.Changes) < int(m.limit) {
+				return
+			}
+
+			for i := range changes.Changes {
+				if changes.Changes[i].ChangeID > fromChangeId {
+					fromChangeId = changes.Changes[i].ChangeID
+				}
+			}
+		}
+	}()
+
+	return pages
+}
+
+// pendingChange - change which waits for address resolution
+type pendingChange struct {
+	change      celestials.Change
+	status      storage.Status
+	quarantined bool
+
+	addressId uint64
+	err       error
+}
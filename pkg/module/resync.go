@@ -0,0 +1,304 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/celenium-io/celestial-module/pkg/storage/postgres"
+	"github.com/pkg/errors"
+)
+
+const (
+	// resyncStateSuffix - suffix of indexer name for state which stores progress of resync
+	resyncStateSuffix = "@resync"
+	// resyncPagesPerSync - max count of pages replayed to shadow table during one sync, so live sync is not blocked
+	resyncPagesPerSync = 10
+)
+
+// ResyncProgress - progress of replaying changes to shadow table
+type ResyncProgress struct {
+	Network   string    `json:"network"`
+	ChangeId  int64     `json:"change_id"`
+	Head      int64     `json:"head"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+type resync struct {
+	mx        sync.RWMutex
+	state     storage.CelestialState
+	head      int64
+	startedAt time.Time
+}
+
+func newResync(state storage.CelestialState) *resync {
+	return &resync{
+		state:     state,
+		startedAt: time.Now().UTC(),
+	}
+}
+
+func (r *resync) progress() ResyncProgress {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	return ResyncProgress{
+		Network:   r.state.Network,
+		ChangeId:  r.state.ChangeId,
+		Head:      r.head,
+		StartedAt: r.startedAt,
+	}
+}
+
+func (r *resync) setChangeId(changeId int64) {
+	r.mx.Lock()
+	r.state.ChangeId = changeId
+	r.mx.Unlock()
+}
+
+func (r *resync) setHead(head int64) {
+	r.mx.Lock()
+	r.head = head
+	r.mx.Unlock()
+}
+
+// Resync - starts replaying all changes of the passed networks (all networks if nothing is passed) from change id 0
+// into shadow table while live table keeps serving reads. When the shadow table catches up with the live state,
+// celestial ids of the network are replaced by the shadow ones in one transaction. Progress is saved, so resync
+// continues after restart.
+func (m *Module) Resync(ctx context.Context, networks ...string) error {
+	if !m.IsLeader() {
+		return errors.New("resync can be started only by leader")
+	}
+
+	targets := m.networks
+	if len(networks) > 0 {
+		targets = make([]*networkSync, 0, len(networks))
+		for _, name := range networks {
+			n, err := m.network(name)
+			if err != nil {
+				return err
+			}
+			targets = append(targets, n)
+		}
+	}
+
+	for _, n := range targets {
+		if n.resync.Load() != nil {
+			return errors.Errorf("resync of %s is already running", n.name)
+		}
+	}
+
+	for _, n := range targets {
+		state, err := m.startResync(ctx, n)
+		if err != nil {
+			return errors.Wrap(err, n.name)
+		}
+		n.resync.Store(newResync(state))
+		m.Log.Info().Str("network", n.name).Msg("resync is started")
+	}
+
+	m.Trigger()
+	return nil
+}
+
+// ResyncProgress - returns progress of running resyncs
+func (m *Module) ResyncProgress() []ResyncProgress {
+	result := make([]ResyncProgress, 0)
+	for _, n := range m.networks {
+		if r := n.resync.Load(); r != nil {
+			result = append(result, r.progress())
+		}
+	}
+	return result
+}
+
+func (m *Module) resyncState(n *networkSync) storage.CelestialState {
+	return storage.CelestialState{
+		Name:    m.indexerName + resyncStateSuffix,
+		Network: n.name,
+	}
+}
+
+// startResync - creates empty shadow table for the network and saves zero progress
+func (m *Module) startResync(ctx context.Context, n *networkSync) (storage.CelestialState, error) {
+	requestCtx, cancel := context.WithTimeout(ctx, m.databaseTimeout)
+	defer cancel()
+
+	state := m.resyncState(n)
+
+	tx, err := postgres.BeginCelestialTransaction(requestCtx, m.tx)
+	if err != nil {
+		return state, errors.Wrap(err, "begin transactions")
+	}
+	defer tx.Close(requestCtx)
+
+	if err := tx.CreateShadow(requestCtx, n.name); err != nil {
+		return state, tx.HandleError(requestCtx, errors.Wrap(err, "create shadow"))
+	}
+	if err := tx.DeleteState(requestCtx, &state); err != nil {
+		return state, tx.HandleError(requestCtx, errors.Wrap(err, "delete resync state"))
+	}
+	if err := tx.Flush(requestCtx); err != nil {
+		return state, errors.Wrap(err, "flush")
+	}
+
+	return state, m.states.Save(requestCtx, &state)
+}
+
+// loadResync - restores resync of the network which was running before restart
+func (m *Module) loadResync(ctx context.Context, n *networkSync) error {
+	requestCtx, cancel := context.WithTimeout(ctx, m.databaseTimeout)
+	defer cancel()
+
+	state, err := m.states.ByName(requestCtx, m.indexerName+resyncStateSuffix, n.name)
+	switch {
+	case err == nil:
+		n.resync.Store(newResync(state))
+		m.Log.Info().Str("network", n.name).Int64("change_id", state.ChangeId).Msg("resync is continued")
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	default:
+		return errors.Wrap(err, "resync state by name")
+	}
+}
+
+// resyncStep - replays a few pages of changes into shadow table and swaps tables if shadow caught up with live state.
+// If shadow is still behind, next sync is triggered immediately.
+func (m *Module) resyncStep(ctx context.Context, n *networkSync, r *resync) error {
+	for range resyncPagesPerSync {
+		if r.state.ChangeId >= n.state.ChangeId {
+			break
+		}
+
+		changes, err := m.getChanges(ctx, n, r.state.ChangeId)
+		if err != nil {
+			return errors.Wrap(err, "get changes")
+		}
+		r.setHead(changes.Head)
+
+		b, lastId, _, err := m.buildBatch(ctx, n, r.state.ChangeId, changes)
+		if err != nil {
+			return err
+		}
+		if lastId > r.state.ChangeId {
+			if err := m.saveShadow(ctx, r, b, lastId); err != nil {
+				return errors.Wrap(err, "save shadow")
+			}
+		}
+
+		if len(changes.Changes) < int(m.limit) {
+			break
+		}
+	}
+
+	if r.state.ChangeId < n.state.ChangeId {
+		m.Log.Info().
+			Str("network", n.name).
+			Int64("change_id", r.state.ChangeId).
+			Int64("live_change_id", n.state.ChangeId).
+			Msg("resync progress")
+		select {
+		case n.trigger <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+
+	if err := m.swapShadow(ctx, n, r); err != nil {
+		return errors.Wrap(err, "swap shadow")
+	}
+	n.resync.Store(nil)
+	m.Log.Info().Str("network", n.name).Int64("change_id", n.state.ChangeId).Msg("resync is finished")
+	return nil
+}
+
+func (m *Module) saveShadow(ctx context.Context, r *resync, b *batch, lastId int64) error {
+	requestCtx, cancel := context.WithTimeout(ctx, m.databaseTimeout)
+	defer cancel()
+
+	tx, err := postgres.BeginCelestialTransaction(requestCtx, m.tx)
+	if err != nil {
+		return errors.Wrap(err, "begin transactions")
+	}
+	defer tx.Close(requestCtx)
+
+	if _, err := tx.UpdateShadowStatusForAddress(requestCtx, b.network, b.primaries); err != nil {
+		return tx.HandleError(requestCtx, errors.Wrap(err, "update primary statuses"))
+	}
+	if err := tx.SaveShadowCelestials(requestCtx, maps.Values(b.celestials)); err != nil {
+		return tx.HandleError(requestCtx, errors.Wrap(err, "save celestials"))
+	}
+	if err := tx.SaveShadowHistory(requestCtx, b.history...); err != nil {
+		return tx.HandleError(requestCtx, errors.Wrap(err, "save history"))
+	}
+	// replayed changes which failed before are already stored and keep their retry backoff
+	if err := tx.SaveNewFailedChanges(requestCtx, b.failed...); err != nil {
+		return tx.HandleError(requestCtx, errors.Wrap(err, "save failed changes"))
+	}
+
+	state := r.state
+	state.ChangeId = lastId
+	if err := tx.UpdateState(requestCtx, &state); err != nil {
+		return tx.HandleError(requestCtx, errors.Wrap(err, "update state"))
+	}
+	if err := tx.Flush(requestCtx); err != nil {
+		return errors.Wrap(err, "flush")
+	}
+
+	r.setChangeId(lastId)
+	return nil
+}
+
+// swapShadow - replaces live celestial ids and history of the network by shadow ones and moves live state
+// to the resync one. ChangeMessage with Resynced flag is published for every added, changed and removed celestial id.
+func (m *Module) swapShadow(ctx context.Context, n *networkSync, r *resync) error {
+	requestCtx, cancel := context.WithTimeout(ctx, m.databaseTimeout)
+	defer cancel()
+
+	tx, err := postgres.BeginCelestialTransaction(requestCtx, m.tx)
+	if err != nil {
+		return errors.Wrap(err, "begin transactions")
+	}
+	defer tx.Close(requestCtx)
+
+	before, after, err := tx.SwapShadow(requestCtx, n.name)
+	if err != nil {
+		return tx.HandleError(requestCtx, err)
+	}
+	if err := tx.DeleteState(requestCtx, &r.state); err != nil {
+		return tx.HandleError(requestCtx, errors.Wrap(err, "delete resync state"))
+	}
+
+	state := n.state
+	state.ChangeId = max(state.ChangeId, r.state.ChangeId)
+	if err := tx.UpdateState(requestCtx, &state); err != nil {
+		return tx.HandleError(requestCtx, errors.Wrap(err, "update state"))
+	}
+	if err := tx.Flush(requestCtx); err != nil {
+		return errors.Wrap(err, "flush")
+	}
+
+	m.invalidateNetwork(n.name)
+	b := newBatch(n.name)
+	b.messages = resyncMessages(n.name, before, after)
+	m.publish(ctx, b)
+
+	n.state = state
+	n.health.setChangeId(state.ChangeId)
+	m.metrics.setChangeId(n.name, state.ChangeId, 0)
+	return nil
+}
+
+// resyncMessages - creates messages about celestial ids replaced by swap ordered by celestial id
+func resyncMessages(network string, before, after []storage.Celestial) []ChangeMessage {
+	messages := stateMessages(network, before, after)
+	for i := range messages {
+		messages[i].Resynced = true
+	}
+	return messages
+}
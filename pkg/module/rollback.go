@@ -0,0 +1,94 @@
+package module
+
+import (
+	"context"
+
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/celenium-io/celestial-module/pkg/storage/postgres"
+	"github.com/pkg/errors"
+)
+
+type rollbackRequest struct {
+	changeId int64
+	result   chan error
+}
+
+// Rollback - reverts indexed celestial ids of the network to how they were after applying the change with passed id
+// and syncs again from it. Rollback is executed by sync goroutine of the network, so module must be started and
+// be a leader. Rollback is rejected while resync of the network is running or if change id is less than the first
+// change id in history. ChangeMessage with RolledBack flag is published for every reverted celestial id.
+func (m *Module) Rollback(ctx context.Context, network string, changeId int64) error {
+	if !m.IsLeader() {
+		return errors.New("rollback can be executed only by leader")
+	}
+	n, err := m.network(network)
+	if err != nil {
+		return err
+	}
+
+	req := rollbackRequest{
+		changeId: changeId,
+		result:   make(chan error, 1),
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case n.rollback <- req:
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-req.result:
+		return err
+	}
+}
+
+func (m *Module) rollback(ctx context.Context, n *networkSync, changeId int64) error {
+	if n.resync.Load() != nil {
+		return errors.Errorf("resync of %s is running", n.name)
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx, m.databaseTimeout)
+	defer cancel()
+
+	tx, err := postgres.BeginCelestialTransaction(requestCtx, m.tx)
+	if err != nil {
+		return errors.Wrap(err, "begin transactions")
+	}
+	defer tx.Close(requestCtx)
+
+	state := n.state
+	before, after, err := tx.RollbackTo(requestCtx, &state, changeId)
+	if err != nil {
+		return tx.HandleError(requestCtx, errors.Wrap(err, "rollback"))
+	}
+	if err := tx.Flush(requestCtx); err != nil {
+		return errors.Wrap(err, "flush")
+	}
+
+	m.Log.Info().
+		Str("network", n.name).
+		Int64("from", n.state.ChangeId).
+		Int64("to", state.ChangeId).
+		Msg("rolled back")
+
+	m.invalidateNetwork(n.name)
+	b := newBatch(n.name)
+	b.messages = rollbackMessages(n.name, before, after)
+	m.publish(ctx, b)
+
+	n.state = state
+	n.health.setChangeId(state.ChangeId)
+	m.metrics.setChangeId(n.name, state.ChangeId, 0)
+	return nil
+}
+
+// rollbackMessages - creates messages about celestial ids reverted by rollback ordered by celestial id
+func rollbackMessages(network string, before, after []storage.Celestial) []ChangeMessage {
+	messages := stateMessages(network, before, after)
+	for i := range messages {
+		messages[i].RolledBack = true
+	}
+	return messages
+}
@@ -0,0 +1,32 @@
+package module
+
+import (
+	"context"
+	"time"
+)
+
+// syncAndSchedule - syncs network and returns delay before the next sync
+func (m *Module) syncAndSchedule(ctx context.Context, n *networkSync) time.Duration {
+	prevChangeId := n.state.ChangeId
+	err := m.runSync(ctx, n)
+	return m.nextSyncDelay(n, err, n.state.ChangeId > prevChangeId)
+}
+
+// nextSyncDelay - returns index period if adaptive polling is disabled. Otherwise the next sync starts immediately
+// while the module is behind API head and progresses. Delay is doubled up to max period while API has no new changes
+// or sync fails, and it's reset to min period when new changes are applied.
+func (m *Module) nextSyncDelay(n *networkSync, err error, progressed bool) time.Duration {
+	if m.maxIndexPeriod <= 0 {
+		return m.indexPeriod
+	}
+
+	switch {
+	case err == nil && progressed && n.health.lag() > 0:
+		n.delay = 0
+	case err == nil && progressed:
+		n.delay = m.minIndexPeriod
+	default:
+		n.delay = min(max(n.delay*2, m.minIndexPeriod), m.maxIndexPeriod)
+	}
+	return n.delay
+}
@@ -0,0 +1,35 @@
+package module
+
+import (
+	celestials "github.com/celenium-io/celestial-module/pkg/api"
+)
+
+// UnknownStatusPolicy - defines how module handles changes with status which is not present in storage.Status enum
+type UnknownStatusPolicy int
+
+const (
+	// UnknownStatusFail - sync returns error and does not move forward (default)
+	UnknownStatusFail UnknownStatusPolicy = iota
+	// UnknownStatusQuarantine - change is skipped and stored to failed changes for later replay
+	UnknownStatusQuarantine
+	// UnknownStatusFallback - change is applied with fallback status and stored to failed changes for later replay
+	UnknownStatusFallback
+)
+
+// Quarantined - returns count of changes with unknown status which were quarantined since module start
+func (m *Module) Quarantined() int64 {
+	return m.quarantined.Load()
+}
+
+func (m *Module) quarantine(network string, change celestials.Change, err error) {
+	count := m.quarantined.Add(1)
+	m.metrics.quarantine(network)
+	m.Log.Warn().
+		Err(err).
+		Str("network", network).
+		Str("celestial_id", change.CelestialID).
+		Int64("change_id", change.ChangeID).
+		Str("status", change.Status).
+		Int64("quarantined_count", count).
+		Msg("unknown status")
+}
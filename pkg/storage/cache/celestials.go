@@ -0,0 +1,237 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultSize = 10_000
+	defaultTTL  = time.Minute
+)
+
+type idKey struct {
+	network string
+	id      string
+}
+
+type addressKey struct {
+	network   string
+	addressId uint64
+}
+
+// primaryEntry - cached primary celestial id of address. Absence of primary celestial id is cached too.
+type primaryEntry struct {
+	celestial storage.Celestial
+	found     bool
+}
+
+// Celestials - read-through cache of storage.ICelestial. Lookups by celestial id and primary celestial ids of addresses
+// are cached in bounded LRU caches with time to live, list queries are passed to the backend. Cache should be
+// invalidated by changes of celestial ids, module does it if the cache is passed with WithCacheInvalidator option.
+type Celestials struct {
+	backend storage.ICelestial
+
+	mx      sync.Mutex
+	byId    *lru[idKey, storage.Celestial]
+	primary *lru[addressKey, primaryEntry]
+	// generation - incremented by every invalidation. Results of backend queries started before invalidation
+	// are not cached because they could be received before the change was committed.
+	generation uint64
+	now        func() time.Time
+}
+
+var _ storage.ICelestial = (*Celestials)(nil)
+
+// NewCelestials - creates cache which keeps at most size celestial ids and size addresses during ttl.
+// Default size is 10000 entries and default ttl is one minute.
+func NewCelestials(backend storage.ICelestial, size int, ttl time.Duration) *Celestials {
+	if size <= 0 {
+		size = defaultSize
+	}
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	return &Celestials{
+		backend: backend,
+		byId:    newLRU[idKey, storage.Celestial](size, ttl),
+		primary: newLRU[addressKey, primaryEntry](size, ttl),
+		now:     time.Now,
+	}
+}
+
+func (c *Celestials) ById(ctx context.Context, network, id string) (storage.Celestial, error) {
+	key := idKey{network, id}
+
+	c.mx.Lock()
+	if cached, ok := c.byId.get(key, c.now()); ok {
+		c.mx.Unlock()
+		return cached, nil
+	}
+	generation := c.generation
+	c.mx.Unlock()
+
+	result, err := c.backend.ById(ctx, network, id)
+	if err != nil {
+		return result, err
+	}
+
+	c.store(generation, func(now time.Time) {
+		c.byId.set(key, result, now)
+	})
+	return result, nil
+}
+
+func (c *Celestials) ByIds(ctx context.Context, network string, ids []string) ([]storage.Celestial, error) {
+	result := make([]storage.Celestial, 0, len(ids))
+	missing := make([]string, 0)
+
+	c.mx.Lock()
+	now := c.now()
+	for _, id := range ids {
+		if cached, ok := c.byId.get(idKey{network, id}, now); ok {
+			result = append(result, cached)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	generation := c.generation
+	c.mx.Unlock()
+
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	received, err := c.backend.ByIds(ctx, network, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	c.store(generation, func(now time.Time) {
+		for i := range received {
+			c.byId.set(idKey{network, received[i].Id}, received[i], now)
+		}
+	})
+	return append(result, received...), nil
+}
+
+func (c *Celestials) Primary(ctx context.Context, network string, addressId uint64) (storage.Celestial, error) {
+	key := addressKey{network, addressId}
+
+	c.mx.Lock()
+	if cached, ok := c.primary.get(key, c.now()); ok {
+		c.mx.Unlock()
+		if !cached.found {
+			return storage.Celestial{}, sql.ErrNoRows
+		}
+		return cached.celestial, nil
+	}
+	generation := c.generation
+	c.mx.Unlock()
+
+	result, err := c.backend.Primary(ctx, network, addressId)
+	switch {
+	case err == nil:
+		c.store(generation, func(now time.Time) {
+			c.primary.set(key, primaryEntry{celestial: result, found: true}, now)
+		})
+	case errors.Is(err, sql.ErrNoRows):
+		c.store(generation, func(now time.Time) {
+			c.primary.set(key, primaryEntry{}, now)
+		})
+	}
+	return result, err
+}
+
+func (c *Celestials) PrimaryByAddressIds(ctx context.Context, network string, addressIds []uint64) (map[uint64]storage.Celestial, error) {
+	result := make(map[uint64]storage.Celestial, len(addressIds))
+	missing := make([]uint64, 0)
+
+	c.mx.Lock()
+	now := c.now()
+	for _, addressId := range addressIds {
+		cached, ok := c.primary.get(addressKey{network, addressId}, now)
+		switch {
+		case !ok:
+			missing = append(missing, addressId)
+		case cached.found:
+			result[addressId] = cached.celestial
+		}
+	}
+	generation := c.generation
+	c.mx.Unlock()
+
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	received, err := c.backend.PrimaryByAddressIds(ctx, network, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	c.store(generation, func(now time.Time) {
+		for _, addressId := range missing {
+			primary, found := received[addressId]
+			c.primary.set(addressKey{network, addressId}, primaryEntry{celestial: primary, found: found}, now)
+		}
+	})
+	for addressId, primary := range received {
+		result[addressId] = primary
+	}
+	return result, nil
+}
+
+func (c *Celestials) ByAddressId(ctx context.Context, network string, addressId uint64, opts storage.ListOptions) ([]storage.Celestial, storage.Cursor, error) {
+	return c.backend.ByAddressId(ctx, network, addressId, opts)
+}
+
+// Search - is not cached. Invalid limit and offset are rejected without querying backend.
+func (c *Celestials) Search(ctx context.Context, network, query string, limit, offset int, status ...storage.Status) ([]storage.Celestial, error) {
+	if err := storage.ValidateLimit(limit); err != nil {
+		return nil, err
+	}
+	if err := storage.ValidateOffset(offset); err != nil {
+		return nil, err
+	}
+	return c.backend.Search(ctx, network, query, limit, offset, status...)
+}
+
+// Invalidate - removes cached celestial ids and primary celestial ids of addresses
+func (c *Celestials) Invalidate(network string, ids []string, addressIds []uint64) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.generation++
+	for _, id := range ids {
+		c.byId.remove(idKey{network, id})
+	}
+	for _, addressId := range addressIds {
+		c.primary.remove(addressKey{network, addressId})
+	}
+}
+
+// InvalidateNetwork - removes all cached entries of network
+func (c *Celestials) InvalidateNetwork(network string) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.generation++
+	c.byId.removeFunc(func(key idKey) bool { return key.network == network })
+	c.primary.removeFunc(func(key addressKey) bool { return key.network == network })
+}
+
+// store - saves result of backend query if cache was not invalidated since the query started
+func (c *Celestials) store(generation uint64, save func(now time.Time)) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.generation == generation {
+		save(c.now())
+	}
+}
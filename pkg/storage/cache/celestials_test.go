@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/celenium-io/celestial-module/pkg/storage/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const testNetwork = "celestia"
+
+func TestCelestialsById(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	backend := mock.NewMockICelestial(ctrl)
+	cache := NewCelestials(backend, 10, time.Minute)
+
+	backend.EXPECT().
+		ById(gomock.Any(), testNetwork, "name").
+		Return(storage.Celestial{Id: "name", Network: testNetwork, AddressId: 1}, nil).
+		Times(2)
+
+	for range 3 {
+		item, err := cache.ById(t.Context(), testNetwork, "name")
+		require.NoError(t, err)
+		require.EqualValues(t, 1, item.AddressId)
+	}
+
+	cache.Invalidate(testNetwork, []string{"name"}, nil)
+	_, err := cache.ById(t.Context(), testNetwork, "name")
+	require.NoError(t, err)
+
+	backend.EXPECT().
+		ById(gomock.Any(), testNetwork, "unknown").
+		Return(storage.Celestial{}, sql.ErrNoRows).
+		Times(2)
+	for range 2 {
+		_, err := cache.ById(t.Context(), testNetwork, "unknown")
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	}
+}
+
+func TestCelestialsPrimaryNegative(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	backend := mock.NewMockICelestial(ctrl)
+	cache := NewCelestials(backend, 10, time.Minute)
+
+	backend.EXPECT().
+		Primary(gomock.Any(), testNetwork, uint64(1)).
+		Return(storage.Celestial{}, sql.ErrNoRows).
+		Times(1)
+
+	for range 2 {
+		_, err := cache.Primary(t.Context(), testNetwork, 1)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	}
+
+	primaries, err := cache.PrimaryByAddressIds(t.Context(), testNetwork, []uint64{1})
+	require.NoError(t, err)
+	require.Empty(t, primaries)
+
+	cache.Invalidate(testNetwork, []string{"name"}, []uint64{1})
+	backend.EXPECT().
+		Primary(gomock.Any(), testNetwork, uint64(1)).
+		Return(storage.Celestial{Id: "name", AddressId: 1, Status: storage.StatusPRIMARY}, nil).
+		Times(1)
+
+	for range 2 {
+		item, err := cache.Primary(t.Context(), testNetwork, 1)
+		require.NoError(t, err)
+		require.Equal(t, "name", item.Id)
+	}
+}
+
+func TestCelestialsSearchInvalidPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	backend := mock.NewMockICelestial(ctrl)
+	cache := NewCelestials(backend, 10, time.Minute)
+
+	_, err := cache.Search(t.Context(), testNetwork, "name", 0, 0)
+	require.ErrorIs(t, err, storage.ErrInvalidLimit)
+
+	_, err = cache.Search(t.Context(), testNetwork, "name", 10, -1)
+	require.ErrorIs(t, err, storage.ErrInvalidOffset)
+
+	backend.EXPECT().
+		Search(gomock.Any(), testNetwork, "name", 10, 0).
+		Return(nil, sql.ErrConnDone).
+		Times(2)
+	for range 2 {
+		_, err := cache.Search(t.Context(), testNetwork, "name", 10, 0)
+		require.ErrorIs(t, err, sql.ErrConnDone)
+	}
+}
+
+func TestCelestialsBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	backend := mock.NewMockICelestial(ctrl)
+	cache := NewCelestials(backend, 10, time.Minute)
+
+	backend.EXPECT().
+		ById(gomock.Any(), testNetwork, "a").
+		Return(storage.Celestial{Id: "a"}, nil).
+		Times(1)
+	_, err := cache.ById(t.Context(), testNetwork, "a")
+	require.NoError(t, err)
+
+	backend.EXPECT().
+		ByIds(gomock.Any(), testNetwork, []string{"b", "c"}).
+		Return([]storage.Celestial{{Id: "b"}}, nil).
+		Times(1)
+	items, err := cache.ByIds(t.Context(), testNetwork, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	backend.EXPECT().
+		PrimaryByAddressIds(gomock.Any(), testNetwork, []uint64{1, 2}).
+		Return(map[uint64]storage.Celestial{1: {Id: "a", AddressId: 1}}, nil).
+		Times(1)
+	for range 2 {
+		primaries, err := cache.PrimaryByAddressIds(t.Context(), testNetwork, []uint64{1, 2})
+		require.NoError(t, err)
+		require.Len(t, primaries, 1)
+		require.Equal(t, "a", primaries[1].Id)
+	}
+
+	_, err = cache.Primary(t.Context(), testNetwork, 2)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCelestialsEviction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	backend := mock.NewMockICelestial(ctrl)
+	cache := NewCelestials(backend, 2, time.Minute)
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	backend.EXPECT().
+		ById(gomock.Any(), testNetwork, gomock.Any()).
+		DoAndReturn(func(_ context.Context, network, id string) (storage.Celestial, error) {
+			return storage.Celestial{Id: id, Network: network}, nil
+		}).
+		Times(5)
+
+	for _, id := range []string{"a", "b", "a", "c"} {
+		_, err := cache.ById(t.Context(), testNetwork, id)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, cache.byId.len())
+
+	// "b" is evicted as least recently used
+	_, err := cache.ById(t.Context(), testNetwork, "b")
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute + time.Second)
+	_, err = cache.ById(t.Context(), testNetwork, "b")
+	require.NoError(t, err)
+
+	cache.InvalidateNetwork(testNetwork)
+	require.Zero(t, cache.byId.len())
+}
+
+func TestCelestialsInvalidationDuringQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	backend := mock.NewMockICelestial(ctrl)
+	cache := NewCelestials(backend, 10, time.Minute)
+
+	backend.EXPECT().
+		ById(gomock.Any(), testNetwork, "name").
+		DoAndReturn(func(_ context.Context, network, id string) (storage.Celestial, error) {
+			// change is committed while the old value is returned
+			cache.Invalidate(network, []string{id}, nil)
+			return storage.Celestial{Id: id, AddressId: 1}, nil
+		}).
+		Times(1)
+	backend.EXPECT().
+		ById(gomock.Any(), testNetwork, "name").
+		Return(storage.Celestial{Id: "name", AddressId: 2}, nil).
+		Times(1)
+
+	item, err := cache.ById(t.Context(), testNetwork, "name")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, item.AddressId)
+
+	item, err = cache.ById(t.Context(), testNetwork, "name")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, item.AddressId)
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+type lruEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// lru - bounded least recently used cache with time to live of entries. It is not safe for concurrent use.
+type lru[K comparable, V any] struct {
+	size  int
+	ttl   time.Duration
+	items map[K]*list.Element
+	order *list.List
+}
+
+func newLRU[K comparable, V any](size int, ttl time.Duration) *lru[K, V] {
+	return &lru[K, V]{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[K]*list.Element, size),
+		order: list.New(),
+	}
+}
+
+func (c *lru[K, V]) get(key K, now time.Time) (value V, ok bool) {
+	elem, ok := c.items[key]
+	if !ok {
+		return value, false
+	}
+	entry := elem.Value.(*lruEntry[K, V])
+	if now.After(entry.expiresAt) {
+		c.removeElement(elem)
+		return value, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (c *lru[K, V]) set(key K, value V, now time.Time) {
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*lruEntry[K, V])
+		entry.value = value
+		entry.expiresAt = now.Add(c.ttl)
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{
+		key:       key,
+		value:     value,
+		expiresAt: now.Add(c.ttl),
+	})
+	for c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *lru[K, V]) remove(key K) {
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// removeFunc - removes all entries which keys satisfy the predicate
+func (c *lru[K, V]) removeFunc(predicate func(K) bool) {
+	for key, elem := range c.items {
+		if predicate(key) {
+			c.removeElement(elem)
+		}
+	}
+}
+
+func (c *lru[K, V]) len() int {
+	return c.order.Len()
+}
+
+func (c *lru[K, V]) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*lruEntry[K, V]).key)
+}
@@ -3,25 +3,40 @@ package storage
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/uptrace/bun"
 )
 
 //go:generate mockgen -source=$GOFILE -destination=mock/$GOFILE -package=mock -typed
 type ICelestial interface {
-	ById(ctx context.Context, id string) (Celestial, error)
-	ByAddressId(ctx context.Context, addressId uint64, limit, offset int) ([]Celestial, error)
-	Primary(ctx context.Context, addressId uint64) (Celestial, error)
+	ById(ctx context.Context, network, id string) (Celestial, error)
+	// ByAddressId - returns page of celestial ids connected to address and cursor of the next page.
+	// Cursor is empty for the last page. It returns ErrInvalidLimit if limit is not in range [1, MaxLimit].
+	ByAddressId(ctx context.Context, network string, addressId uint64, opts ListOptions) ([]Celestial, Cursor, error)
+	Primary(ctx context.Context, network string, addressId uint64) (Celestial, error)
+	// ByIds - returns celestial ids with passed ids. Absent ids are skipped.
+	ByIds(ctx context.Context, network string, ids []string) ([]Celestial, error)
+	// PrimaryByAddressIds - returns primary celestial ids of addresses. Addresses without primary celestial id are absent in the result.
+	PrimaryByAddressIds(ctx context.Context, network string, addressIds []uint64) (map[uint64]Celestial, error)
+	// Search - returns celestial ids matching the query by prefix, substring or trigram similarity.
+	// If statuses are passed, only celestial ids with these statuses are returned. It returns ErrInvalidLimit if limit
+	// is not in range [1, MaxLimit] and ErrInvalidOffset if offset is negative.
+	Search(ctx context.Context, network, query string, limit, offset int, status ...Status) ([]Celestial, error)
 }
 
 type Celestial struct {
 	bun.BaseModel `bun:"celestial" comment:"Table with celestial ids."`
 
-	Id        string `bun:"id,pk,notnull"                 comment:"Celestial id"`
-	AddressId uint64 `bun:"address_id"                    comment:"Internal address identity for connected address"`
-	ImageUrl  string `bun:"image_url"                     comment:"Image url"`
-	ChangeId  int64  `bun:"change_id"                     comment:"Id of the last change of celestial id"`
-	Status    Status `bun:"status,type:celestials_status" comment:"Status of celestial domain"`
+	Id              string    `bun:"id,pk,notnull"                                    comment:"Celestial id"`
+	Network         string    `bun:"network,pk,notnull"                               comment:"Network (chain id) of celestial id"`
+	AddressId       uint64    `bun:"address_id"                                       comment:"Internal address identity for connected address"`
+	ImageUrl        string    `bun:"image_url"                                        comment:"Image url"`
+	ChangeId        int64     `bun:"change_id"                                        comment:"Id of the last change of celestial id"`
+	Status          Status    `bun:"status,type:celestials_status"                    comment:"Status of celestial domain"`
+	CreatedAt       time.Time `bun:"created_at,nullzero,notnull,default:now()"        comment:"Time when celestial id was indexed first time"`
+	UpdatedAt       time.Time `bun:"updated_at,nullzero,notnull,default:now()"        comment:"Time of the last update of celestial id"`
+	StatusChangedAt time.Time `bun:"status_changed_at,nullzero,notnull,default:now()" comment:"Time of the last status change of celestial id"`
 }
 
 func (Celestial) TableName() string {
@@ -29,5 +44,5 @@ func (Celestial) TableName() string {
 }
 
 func (cid Celestial) String() string {
-	return fmt.Sprintf("%s %s", cid.Id, cid.ImageUrl)
+	return fmt.Sprintf("%s %s %s", cid.Network, cid.Id, cid.ImageUrl)
 }
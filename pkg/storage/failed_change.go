@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=mock/$GOFILE -package=mock -typed
+type ICelestialFailedChange interface {
+	// List - returns failed changes ordered by change id. It returns ErrInvalidLimit if limit is not in range [1, MaxLimit].
+	List(ctx context.Context, network string, limit, offset int) ([]CelestialFailedChange, error)
+}
+
+type CelestialFailedChange struct {
+	bun.BaseModel `bun:"celestial_failed_change" comment:"Table with changes which were not applied because of errors."`
+
+	ChangeId    int64     `bun:"change_id,pk,notnull"  comment:"Id of the change"`
+	Network     string    `bun:"network,pk,notnull"    comment:"Network (chain id) of the change"`
+	CelestialId string    `bun:"celestial_id,notnull"  comment:"Celestial id"`
+	Address     string    `bun:"address"               comment:"Connected address"`
+	ImageUrl    string    `bun:"image_url"             comment:"Image url"`
+	Status      string    `bun:"status"                comment:"Raw status of celestial domain received from API"`
+	Error       string    `bun:"error"                 comment:"Text of the last error"`
+	Attempts    int       `bun:"attempts"              comment:"Count of processing attempts"`
+	NextRetryAt time.Time `bun:"next_retry_at,notnull" comment:"Time of the next processing attempt"`
+}
+
+func (CelestialFailedChange) TableName() string {
+	return "celestial_failed_change"
+}
+
+func (fc CelestialFailedChange) String() string {
+	return fmt.Sprintf("%s %d %s %s", fc.Network, fc.ChangeId, fc.CelestialId, fc.Error)
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=mock/$GOFILE -package=mock -typed
+type ICelestialHistory interface {
+	// ById - returns changes of celestial id after the passed change id. List queries of history return ErrInvalidLimit
+	// if limit is not in range [1, MaxLimit].
+	ById(ctx context.Context, network, id string, fromChangeId int64, limit int) ([]CelestialHistory, error)
+	ByAddressId(ctx context.Context, network string, addressId uint64, fromChangeId int64, limit int) ([]CelestialHistory, error)
+
+	// ByIdAt - returns celestial id as it was after applying the change with passed id
+	ByIdAt(ctx context.Context, network, id string, changeId int64) (Celestial, error)
+	// ByAddressIdAt - returns celestial ids connected to address after applying the change with passed id
+	ByAddressIdAt(ctx context.Context, network string, addressId uint64, changeId int64, limit, offset int) ([]Celestial, error)
+	// PrimaryAtChangeId - returns primary celestial id of address after applying the change with passed id.
+	// It resolves the primary name at the time of a historical transaction by the last change id preceding it.
+	PrimaryAtChangeId(ctx context.Context, network string, addressId uint64, changeId int64) (Celestial, error)
+	// PrimaryAt - returns primary celestial id of address as it was served by the module at the passed time.
+	// Time is compared with indexed_at, the time when the module indexed the change.
+	PrimaryAt(ctx context.Context, network string, addressId uint64, at time.Time) (Celestial, error)
+}
+
+type CelestialHistory struct {
+	bun.BaseModel `bun:"celestial_history" comment:"Table with history of applied celestial ids changes."`
+
+	IndexedAt   time.Time `bun:"indexed_at,pk,notnull"         comment:"Time when change was indexed"`
+	ChangeId    int64     `bun:"change_id,pk,notnull"          comment:"Id of the change"`
+	Network     string    `bun:"network,pk,notnull"            comment:"Network (chain id) of the change"`
+	CelestialId string    `bun:"celestial_id,notnull"          comment:"Celestial id"`
+	AddressId   uint64    `bun:"address_id"                    comment:"Internal address identity for connected address"`
+	ImageUrl    string    `bun:"image_url"                     comment:"Image url"`
+	Status      Status    `bun:"status,type:celestials_status" comment:"Status of celestial domain"`
+}
+
+func (CelestialHistory) TableName() string {
+	return "celestial_history"
+}
+
+func (h CelestialHistory) String() string {
+	return fmt.Sprintf("%s %d %s %d %s", h.Network, h.ChangeId, h.CelestialId, h.AddressId, h.Status)
+}
@@ -0,0 +1,15 @@
+package storage
+
+import (
+	"context"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=mock/$GOFILE -package=mock -typed
+type ILeaderLock interface {
+	// TryLock - tries to acquire the lock without waiting. It returns false if the lock is held by another instance.
+	TryLock(ctx context.Context) (bool, error)
+	// Check - returns error if the acquired lock was lost, e.g. database session was terminated
+	Check(ctx context.Context) error
+	// Unlock - releases the acquired lock
+	Unlock(ctx context.Context) error
+}
@@ -42,18 +42,19 @@ func (m *MockICelestial) EXPECT() *MockICelestialMockRecorder {
 }
 
 // ByAddressId mocks base method.
-func (m *MockICelestial) ByAddressId(ctx context.Context, addressId uint64, limit, offset int) ([]storage.Celestial, error) {
+func (m *MockICelestial) ByAddressId(ctx context.Context, network string, addressId uint64, opts storage.ListOptions) ([]storage.Celestial, storage.Cursor, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ByAddressId", ctx, addressId, limit, offset)
+	ret := m.ctrl.Call(m, "ByAddressId", ctx, network, addressId, opts)
 	ret0, _ := ret[0].([]storage.Celestial)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(storage.Cursor)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // ByAddressId indicates an expected call of ByAddressId.
-func (mr *MockICelestialMockRecorder) ByAddressId(ctx, addressId, limit, offset any) *MockICelestialByAddressIdCall {
+func (mr *MockICelestialMockRecorder) ByAddressId(ctx, network, addressId, opts any) *MockICelestialByAddressIdCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByAddressId", reflect.TypeOf((*MockICelestial)(nil).ByAddressId), ctx, addressId, limit, offset)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByAddressId", reflect.TypeOf((*MockICelestial)(nil).ByAddressId), ctx, network, addressId, opts)
 	return &MockICelestialByAddressIdCall{Call: call}
 }
 
@@ -63,36 +64,36 @@ type MockICelestialByAddressIdCall struct {
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockICelestialByAddressIdCall) Return(arg0 []storage.Celestial, arg1 error) *MockICelestialByAddressIdCall {
-	c.Call = c.Call.Return(arg0, arg1)
+func (c *MockICelestialByAddressIdCall) Return(arg0 []storage.Celestial, arg1 storage.Cursor, arg2 error) *MockICelestialByAddressIdCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockICelestialByAddressIdCall) Do(f func(context.Context, uint64, int, int) ([]storage.Celestial, error)) *MockICelestialByAddressIdCall {
+func (c *MockICelestialByAddressIdCall) Do(f func(context.Context, string, uint64, storage.ListOptions) ([]storage.Celestial, storage.Cursor, error)) *MockICelestialByAddressIdCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockICelestialByAddressIdCall) DoAndReturn(f func(context.Context, uint64, int, int) ([]storage.Celestial, error)) *MockICelestialByAddressIdCall {
+func (c *MockICelestialByAddressIdCall) DoAndReturn(f func(context.Context, string, uint64, storage.ListOptions) ([]storage.Celestial, storage.Cursor, error)) *MockICelestialByAddressIdCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
 // ById mocks base method.
-func (m *MockICelestial) ById(ctx context.Context, id string) (storage.Celestial, error) {
+func (m *MockICelestial) ById(ctx context.Context, network, id string) (storage.Celestial, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ById", ctx, id)
+	ret := m.ctrl.Call(m, "ById", ctx, network, id)
 	ret0, _ := ret[0].(storage.Celestial)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ById indicates an expected call of ById.
-func (mr *MockICelestialMockRecorder) ById(ctx, id any) *MockICelestialByIdCall {
+func (mr *MockICelestialMockRecorder) ById(ctx, network, id any) *MockICelestialByIdCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ById", reflect.TypeOf((*MockICelestial)(nil).ById), ctx, id)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ById", reflect.TypeOf((*MockICelestial)(nil).ById), ctx, network, id)
 	return &MockICelestialByIdCall{Call: call}
 }
 
@@ -108,30 +109,69 @@ func (c *MockICelestialByIdCall) Return(arg0 storage.Celestial, arg1 error) *Moc
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockICelestialByIdCall) Do(f func(context.Context, string) (storage.Celestial, error)) *MockICelestialByIdCall {
+func (c *MockICelestialByIdCall) Do(f func(context.Context, string, string) (storage.Celestial, error)) *MockICelestialByIdCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockICelestialByIdCall) DoAndReturn(f func(context.Context, string, string) (storage.Celestial, error)) *MockICelestialByIdCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ByIds mocks base method.
+func (m *MockICelestial) ByIds(ctx context.Context, network string, ids []string) ([]storage.Celestial, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ByIds", ctx, network, ids)
+	ret0, _ := ret[0].([]storage.Celestial)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ByIds indicates an expected call of ByIds.
+func (mr *MockICelestialMockRecorder) ByIds(ctx, network, ids any) *MockICelestialByIdsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByIds", reflect.TypeOf((*MockICelestial)(nil).ByIds), ctx, network, ids)
+	return &MockICelestialByIdsCall{Call: call}
+}
+
+// MockICelestialByIdsCall wrap *gomock.Call
+type MockICelestialByIdsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockICelestialByIdsCall) Return(arg0 []storage.Celestial, arg1 error) *MockICelestialByIdsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockICelestialByIdsCall) Do(f func(context.Context, string, []string) ([]storage.Celestial, error)) *MockICelestialByIdsCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockICelestialByIdCall) DoAndReturn(f func(context.Context, string) (storage.Celestial, error)) *MockICelestialByIdCall {
+func (c *MockICelestialByIdsCall) DoAndReturn(f func(context.Context, string, []string) ([]storage.Celestial, error)) *MockICelestialByIdsCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
 // Primary mocks base method.
-func (m *MockICelestial) Primary(ctx context.Context, addressId uint64) (storage.Celestial, error) {
+func (m *MockICelestial) Primary(ctx context.Context, network string, addressId uint64) (storage.Celestial, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Primary", ctx, addressId)
+	ret := m.ctrl.Call(m, "Primary", ctx, network, addressId)
 	ret0, _ := ret[0].(storage.Celestial)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Primary indicates an expected call of Primary.
-func (mr *MockICelestialMockRecorder) Primary(ctx, addressId any) *MockICelestialPrimaryCall {
+func (mr *MockICelestialMockRecorder) Primary(ctx, network, addressId any) *MockICelestialPrimaryCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Primary", reflect.TypeOf((*MockICelestial)(nil).Primary), ctx, addressId)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Primary", reflect.TypeOf((*MockICelestial)(nil).Primary), ctx, network, addressId)
 	return &MockICelestialPrimaryCall{Call: call}
 }
 
@@ -147,13 +187,96 @@ func (c *MockICelestialPrimaryCall) Return(arg0 storage.Celestial, arg1 error) *
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockICelestialPrimaryCall) Do(f func(context.Context, uint64) (storage.Celestial, error)) *MockICelestialPrimaryCall {
+func (c *MockICelestialPrimaryCall) Do(f func(context.Context, string, uint64) (storage.Celestial, error)) *MockICelestialPrimaryCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockICelestialPrimaryCall) DoAndReturn(f func(context.Context, string, uint64) (storage.Celestial, error)) *MockICelestialPrimaryCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// PrimaryByAddressIds mocks base method.
+func (m *MockICelestial) PrimaryByAddressIds(ctx context.Context, network string, addressIds []uint64) (map[uint64]storage.Celestial, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrimaryByAddressIds", ctx, network, addressIds)
+	ret0, _ := ret[0].(map[uint64]storage.Celestial)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PrimaryByAddressIds indicates an expected call of PrimaryByAddressIds.
+func (mr *MockICelestialMockRecorder) PrimaryByAddressIds(ctx, network, addressIds any) *MockICelestialPrimaryByAddressIdsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrimaryByAddressIds", reflect.TypeOf((*MockICelestial)(nil).PrimaryByAddressIds), ctx, network, addressIds)
+	return &MockICelestialPrimaryByAddressIdsCall{Call: call}
+}
+
+// MockICelestialPrimaryByAddressIdsCall wrap *gomock.Call
+type MockICelestialPrimaryByAddressIdsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockICelestialPrimaryByAddressIdsCall) Return(arg0 map[uint64]storage.Celestial, arg1 error) *MockICelestialPrimaryByAddressIdsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockICelestialPrimaryByAddressIdsCall) Do(f func(context.Context, string, []uint64) (map[uint64]storage.Celestial, error)) *MockICelestialPrimaryByAddressIdsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockICelestialPrimaryByAddressIdsCall) DoAndReturn(f func(context.Context, string, []uint64) (map[uint64]storage.Celestial, error)) *MockICelestialPrimaryByAddressIdsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Search mocks base method.
+func (m *MockICelestial) Search(ctx context.Context, network, query string, limit, offset int, status ...storage.Status) ([]storage.Celestial, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, network, query, limit, offset}
+	for _, a := range status {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Search", varargs...)
+	ret0, _ := ret[0].([]storage.Celestial)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockICelestialMockRecorder) Search(ctx, network, query, limit, offset any, status ...any) *MockICelestialSearchCall {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, network, query, limit, offset}, status...)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockICelestial)(nil).Search), varargs...)
+	return &MockICelestialSearchCall{Call: call}
+}
+
+// MockICelestialSearchCall wrap *gomock.Call
+type MockICelestialSearchCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockICelestialSearchCall) Return(arg0 []storage.Celestial, arg1 error) *MockICelestialSearchCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockICelestialSearchCall) Do(f func(context.Context, string, string, int, int, ...storage.Status) ([]storage.Celestial, error)) *MockICelestialSearchCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockICelestialPrimaryCall) DoAndReturn(f func(context.Context, uint64) (storage.Celestial, error)) *MockICelestialPrimaryCall {
+func (c *MockICelestialSearchCall) DoAndReturn(f func(context.Context, string, string, int, int, ...storage.Status) ([]storage.Celestial, error)) *MockICelestialSearchCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
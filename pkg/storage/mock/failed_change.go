@@ -0,0 +1,81 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: failed_change.go
+//
+// Generated by this command:
+//
+//	mockgen -source=failed_change.go -destination=mock/failed_change.go -package=mock -typed
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	storage "github.com/celenium-io/celestial-module/pkg/storage"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockICelestialFailedChange is a mock of ICelestialFailedChange interface.
+type MockICelestialFailedChange struct {
+	ctrl     *gomock.Controller
+	recorder *MockICelestialFailedChangeMockRecorder
+	isgomock struct{}
+}
+
+// MockICelestialFailedChangeMockRecorder is the mock recorder for MockICelestialFailedChange.
+type MockICelestialFailedChangeMockRecorder struct {
+	mock *MockICelestialFailedChange
+}
+
+// NewMockICelestialFailedChange creates a new mock instance.
+func NewMockICelestialFailedChange(ctrl *gomock.Controller) *MockICelestialFailedChange {
+	mock := &MockICelestialFailedChange{ctrl: ctrl}
+	mock.recorder = &MockICelestialFailedChangeMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockICelestialFailedChange) EXPECT() *MockICelestialFailedChangeMockRecorder {
+	return m.recorder
+}
+
+// List mocks base method.
+func (m *MockICelestialFailedChange) List(ctx context.Context, network string, limit, offset int) ([]storage.CelestialFailedChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, network, limit, offset)
+	ret0, _ := ret[0].([]storage.CelestialFailedChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockICelestialFailedChangeMockRecorder) List(ctx, network, limit, offset any) *MockICelestialFailedChangeListCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockICelestialFailedChange)(nil).List), ctx, network, limit, offset)
+	return &MockICelestialFailedChangeListCall{Call: call}
+}
+
+// MockICelestialFailedChangeListCall wrap *gomock.Call
+type MockICelestialFailedChangeListCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockICelestialFailedChangeListCall) Return(arg0 []storage.CelestialFailedChange, arg1 error) *MockICelestialFailedChangeListCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockICelestialFailedChangeListCall) Do(f func(context.Context, string, int, int) ([]storage.CelestialFailedChange, error)) *MockICelestialFailedChangeListCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockICelestialFailedChangeListCall) DoAndReturn(f func(context.Context, string, int, int) ([]storage.CelestialFailedChange, error)) *MockICelestialFailedChangeListCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -0,0 +1,277 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: history.go
+//
+// Generated by this command:
+//
+//	mockgen -source=history.go -destination=mock/history.go -package=mock -typed
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	storage "github.com/celenium-io/celestial-module/pkg/storage"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockICelestialHistory is a mock of ICelestialHistory interface.
+type MockICelestialHistory struct {
+	ctrl     *gomock.Controller
+	recorder *MockICelestialHistoryMockRecorder
+	isgomock struct{}
+}
+
+// MockICelestialHistoryMockRecorder is the mock recorder for MockICelestialHistory.
+type MockICelestialHistoryMockRecorder struct {
+	mock *MockICelestialHistory
+}
+
+// NewMockICelestialHistory creates a new mock instance.
+func NewMockICelestialHistory(ctrl *gomock.Controller) *MockICelestialHistory {
+	mock := &MockICelestialHistory{ctrl: ctrl}
+	mock.recorder = &MockICelestialHistoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockICelestialHistory) EXPECT() *MockICelestialHistoryMockRecorder {
+	return m.recorder
+}
+
+// ByAddressId mocks base method.
+func (m *MockICelestialHistory) ByAddressId(ctx context.Context, network string, addressId uint64, fromChangeId int64, limit int) ([]storage.CelestialHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ByAddressId", ctx, network, addressId, fromChangeId, limit)
+	ret0, _ := ret[0].([]storage.CelestialHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ByAddressId indicates an expected call of ByAddressId.
+func (mr *MockICelestialHistoryMockRecorder) ByAddressId(ctx, network, addressId, fromChangeId, limit any) *MockICelestialHistoryByAddressIdCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByAddressId", reflect.TypeOf((*MockICelestialHistory)(nil).ByAddressId), ctx, network, addressId, fromChangeId, limit)
+	return &MockICelestialHistoryByAddressIdCall{Call: call}
+}
+
+// MockICelestialHistoryByAddressIdCall wrap *gomock.Call
+type MockICelestialHistoryByAddressIdCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockICelestialHistoryByAddressIdCall) Return(arg0 []storage.CelestialHistory, arg1 error) *MockICelestialHistoryByAddressIdCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockICelestialHistoryByAddressIdCall) Do(f func(context.Context, string, uint64, int64, int) ([]storage.CelestialHistory, error)) *MockICelestialHistoryByAddressIdCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockICelestialHistoryByAddressIdCall) DoAndReturn(f func(context.Context, string, uint64, int64, int) ([]storage.CelestialHistory, error)) *MockICelestialHistoryByAddressIdCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ByAddressIdAt mocks base method.
+func (m *MockICelestialHistory) ByAddressIdAt(ctx context.Context, network string, addressId uint64, changeId int64, limit, offset int) ([]storage.Celestial, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ByAddressIdAt", ctx, network, addressId, changeId, limit, offset)
+	ret0, _ := ret[0].([]storage.Celestial)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ByAddressIdAt indicates an expected call of ByAddressIdAt.
+func (mr *MockICelestialHistoryMockRecorder) ByAddressIdAt(ctx, network, addressId, changeId, limit, offset any) *MockICelestialHistoryByAddressIdAtCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByAddressIdAt", reflect.TypeOf((*MockICelestialHistory)(nil).ByAddressIdAt), ctx, network, addressId, changeId, limit, offset)
+	return &MockICelestialHistoryByAddressIdAtCall{Call: call}
+}
+
+// MockICelestialHistoryByAddressIdAtCall wrap *gomock.Call
+type MockICelestialHistoryByAddressIdAtCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockICelestialHistoryByAddressIdAtCall) Return(arg0 []storage.Celestial, arg1 error) *MockICelestialHistoryByAddressIdAtCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockICelestialHistoryByAddressIdAtCall) Do(f func(context.Context, string, uint64, int64, int, int) ([]storage.Celestial, error)) *MockICelestialHistoryByAddressIdAtCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockICelestialHistoryByAddressIdAtCall) DoAndReturn(f func(context.Context, string, uint64, int64, int, int) ([]storage.Celestial, error)) *MockICelestialHistoryByAddressIdAtCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ById mocks base method.
+func (m *MockICelestialHistory) ById(ctx context.Context, network, id string, fromChangeId int64, limit int) ([]storage.CelestialHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ById", ctx, network, id, fromChangeId, limit)
+	ret0, _ := ret[0].([]storage.CelestialHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ById indicates an expected call of ById.
+func (mr *MockICelestialHistoryMockRecorder) ById(ctx, network, id, fromChangeId, limit any) *MockICelestialHistoryByIdCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ById", reflect.TypeOf((*MockICelestialHistory)(nil).ById), ctx, network, id, fromChangeId, limit)
+	return &MockICelestialHistoryByIdCall{Call: call}
+}
+
+// MockICelestialHistoryByIdCall wrap *gomock.Call
+type MockICelestialHistoryByIdCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockICelestialHistoryByIdCall) Return(arg0 []storage.CelestialHistory, arg1 error) *MockICelestialHistoryByIdCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockICelestialHistoryByIdCall) Do(f func(context.Context, string, string, int64, int) ([]storage.CelestialHistory, error)) *MockICelestialHistoryByIdCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockICelestialHistoryByIdCall) DoAndReturn(f func(context.Context, string, string, int64, int) ([]storage.CelestialHistory, error)) *MockICelestialHistoryByIdCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ByIdAt mocks base method.
+func (m *MockICelestialHistory) ByIdAt(ctx context.Context, network, id string, changeId int64) (storage.Celestial, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ByIdAt", ctx, network, id, changeId)
+	ret0, _ := ret[0].(storage.Celestial)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ByIdAt indicates an expected call of ByIdAt.
+func (mr *MockICelestialHistoryMockRecorder) ByIdAt(ctx, network, id, changeId any) *MockICelestialHistoryByIdAtCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByIdAt", reflect.TypeOf((*MockICelestialHistory)(nil).ByIdAt), ctx, network, id, changeId)
+	return &MockICelestialHistoryByIdAtCall{Call: call}
+}
+
+// MockICelestialHistoryByIdAtCall wrap *gomock.Call
+type MockICelestialHistoryByIdAtCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockICelestialHistoryByIdAtCall) Return(arg0 storage.Celestial, arg1 error) *MockICelestialHistoryByIdAtCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockICelestialHistoryByIdAtCall) Do(f func(context.Context, string, string, int64) (storage.Celestial, error)) *MockICelestialHistoryByIdAtCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockICelestialHistoryByIdAtCall) DoAndReturn(f func(context.Context, string, string, int64) (storage.Celestial, error)) *MockICelestialHistoryByIdAtCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// PrimaryAt mocks base method.
+func (m *MockICelestialHistory) PrimaryAt(ctx context.Context, network string, addressId uint64, at time.Time) (storage.Celestial, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrimaryAt", ctx, network, addressId, at)
+	ret0, _ := ret[0].(storage.Celestial)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PrimaryAt indicates an expected call of PrimaryAt.
+func (mr *MockICelestialHistoryMockRecorder) PrimaryAt(ctx, network, addressId, at any) *MockICelestialHistoryPrimaryAtCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrimaryAt", reflect.TypeOf((*MockICelestialHistory)(nil).PrimaryAt), ctx, network, addressId, at)
+	return &MockICelestialHistoryPrimaryAtCall{Call: call}
+}
+
+// MockICelestialHistoryPrimaryAtCall wrap *gomock.Call
+type MockICelestialHistoryPrimaryAtCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockICelestialHistoryPrimaryAtCall) Return(arg0 storage.Celestial, arg1 error) *MockICelestialHistoryPrimaryAtCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockICelestialHistoryPrimaryAtCall) Do(f func(context.Context, string, uint64, time.Time) (storage.Celestial, error)) *MockICelestialHistoryPrimaryAtCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockICelestialHistoryPrimaryAtCall) DoAndReturn(f func(context.Context, string, uint64, time.Time) (storage.Celestial, error)) *MockICelestialHistoryPrimaryAtCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// PrimaryAtChangeId mocks base method.
+func (m *MockICelestialHistory) PrimaryAtChangeId(ctx context.Context, network string, addressId uint64, changeId int64) (storage.Celestial, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrimaryAtChangeId", ctx, network, addressId, changeId)
+	ret0, _ := ret[0].(storage.Celestial)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PrimaryAtChangeId indicates an expected call of PrimaryAtChangeId.
+func (mr *MockICelestialHistoryMockRecorder) PrimaryAtChangeId(ctx, network, addressId, changeId any) *MockICelestialHistoryPrimaryAtChangeIdCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrimaryAtChangeId", reflect.TypeOf((*MockICelestialHistory)(nil).PrimaryAtChangeId), ctx, network, addressId, changeId)
+	return &MockICelestialHistoryPrimaryAtChangeIdCall{Call: call}
+}
+
+// MockICelestialHistoryPrimaryAtChangeIdCall wrap *gomock.Call
+type MockICelestialHistoryPrimaryAtChangeIdCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockICelestialHistoryPrimaryAtChangeIdCall) Return(arg0 storage.Celestial, arg1 error) *MockICelestialHistoryPrimaryAtChangeIdCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockICelestialHistoryPrimaryAtChangeIdCall) Do(f func(context.Context, string, uint64, int64) (storage.Celestial, error)) *MockICelestialHistoryPrimaryAtChangeIdCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockICelestialHistoryPrimaryAtChangeIdCall) DoAndReturn(f func(context.Context, string, uint64, int64) (storage.Celestial, error)) *MockICelestialHistoryPrimaryAtChangeIdCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -0,0 +1,156 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: lock.go
+//
+// Generated by this command:
+//
+//	mockgen -source=lock.go -destination=mock/lock.go -package=mock -typed
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockILeaderLock is a mock of ILeaderLock interface.
+type MockILeaderLock struct {
+	ctrl     *gomock.Controller
+	recorder *MockILeaderLockMockRecorder
+	isgomock struct{}
+}
+
+// MockILeaderLockMockRecorder is the mock recorder for MockILeaderLock.
+type MockILeaderLockMockRecorder struct {
+	mock *MockILeaderLock
+}
+
+// NewMockILeaderLock creates a new mock instance.
+func NewMockILeaderLock(ctrl *gomock.Controller) *MockILeaderLock {
+	mock := &MockILeaderLock{ctrl: ctrl}
+	mock.recorder = &MockILeaderLockMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockILeaderLock) EXPECT() *MockILeaderLockMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockILeaderLock) Check(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockILeaderLockMockRecorder) Check(ctx any) *MockILeaderLockCheckCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockILeaderLock)(nil).Check), ctx)
+	return &MockILeaderLockCheckCall{Call: call}
+}
+
+// MockILeaderLockCheckCall wrap *gomock.Call
+type MockILeaderLockCheckCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockILeaderLockCheckCall) Return(arg0 error) *MockILeaderLockCheckCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockILeaderLockCheckCall) Do(f func(context.Context) error) *MockILeaderLockCheckCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockILeaderLockCheckCall) DoAndReturn(f func(context.Context) error) *MockILeaderLockCheckCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// TryLock mocks base method.
+func (m *MockILeaderLock) TryLock(ctx context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TryLock", ctx)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TryLock indicates an expected call of TryLock.
+func (mr *MockILeaderLockMockRecorder) TryLock(ctx any) *MockILeaderLockTryLockCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryLock", reflect.TypeOf((*MockILeaderLock)(nil).TryLock), ctx)
+	return &MockILeaderLockTryLockCall{Call: call}
+}
+
+// MockILeaderLockTryLockCall wrap *gomock.Call
+type MockILeaderLockTryLockCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockILeaderLockTryLockCall) Return(arg0 bool, arg1 error) *MockILeaderLockTryLockCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockILeaderLockTryLockCall) Do(f func(context.Context) (bool, error)) *MockILeaderLockTryLockCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockILeaderLockTryLockCall) DoAndReturn(f func(context.Context) (bool, error)) *MockILeaderLockTryLockCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Unlock mocks base method.
+func (m *MockILeaderLock) Unlock(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockILeaderLockMockRecorder) Unlock(ctx any) *MockILeaderLockUnlockCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockILeaderLock)(nil).Unlock), ctx)
+	return &MockILeaderLockUnlockCall{Call: call}
+}
+
+// MockILeaderLockUnlockCall wrap *gomock.Call
+type MockILeaderLockUnlockCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockILeaderLockUnlockCall) Return(arg0 error) *MockILeaderLockUnlockCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockILeaderLockUnlockCall) Do(f func(context.Context) error) *MockILeaderLockUnlockCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockILeaderLockUnlockCall) DoAndReturn(f func(context.Context) error) *MockILeaderLockUnlockCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
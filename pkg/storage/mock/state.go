@@ -42,18 +42,18 @@ func (m *MockICelestialState) EXPECT() *MockICelestialStateMockRecorder {
 }
 
 // ByName mocks base method.
-func (m *MockICelestialState) ByName(ctx context.Context, name string) (storage.CelestialState, error) {
+func (m *MockICelestialState) ByName(ctx context.Context, name, network string) (storage.CelestialState, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ByName", ctx, name)
+	ret := m.ctrl.Call(m, "ByName", ctx, name, network)
 	ret0, _ := ret[0].(storage.CelestialState)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ByName indicates an expected call of ByName.
-func (mr *MockICelestialStateMockRecorder) ByName(ctx, name any) *MockICelestialStateByNameCall {
+func (mr *MockICelestialStateMockRecorder) ByName(ctx, name, network any) *MockICelestialStateByNameCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByName", reflect.TypeOf((*MockICelestialState)(nil).ByName), ctx, name)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByName", reflect.TypeOf((*MockICelestialState)(nil).ByName), ctx, name, network)
 	return &MockICelestialStateByNameCall{Call: call}
 }
 
@@ -69,13 +69,13 @@ func (c *MockICelestialStateByNameCall) Return(arg0 storage.CelestialState, arg1
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockICelestialStateByNameCall) Do(f func(context.Context, string) (storage.CelestialState, error)) *MockICelestialStateByNameCall {
+func (c *MockICelestialStateByNameCall) Do(f func(context.Context, string, string) (storage.CelestialState, error)) *MockICelestialStateByNameCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockICelestialStateByNameCall) DoAndReturn(f func(context.Context, string) (storage.CelestialState, error)) *MockICelestialStateByNameCall {
+func (c *MockICelestialStateByNameCall) DoAndReturn(f func(context.Context, string, string) (storage.CelestialState, error)) *MockICelestialStateByNameCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
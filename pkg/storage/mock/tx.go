@@ -120,6 +120,84 @@ func (c *MockCelestialTransactionBulkSaveCall) DoAndReturn(f func(context.Contex
 	return c
 }
 
+// CelestialsAfter mocks base method.
+func (m *MockCelestialTransaction) CelestialsAfter(ctx context.Context, network, afterId string, limit int) ([]storage.Celestial, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CelestialsAfter", ctx, network, afterId, limit)
+	ret0, _ := ret[0].([]storage.Celestial)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CelestialsAfter indicates an expected call of CelestialsAfter.
+func (mr *MockCelestialTransactionMockRecorder) CelestialsAfter(ctx, network, afterId, limit any) *MockCelestialTransactionCelestialsAfterCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CelestialsAfter", reflect.TypeOf((*MockCelestialTransaction)(nil).CelestialsAfter), ctx, network, afterId, limit)
+	return &MockCelestialTransactionCelestialsAfterCall{Call: call}
+}
+
+// MockCelestialTransactionCelestialsAfterCall wrap *gomock.Call
+type MockCelestialTransactionCelestialsAfterCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockCelestialTransactionCelestialsAfterCall) Return(arg0 []storage.Celestial, arg1 error) *MockCelestialTransactionCelestialsAfterCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockCelestialTransactionCelestialsAfterCall) Do(f func(context.Context, string, string, int) ([]storage.Celestial, error)) *MockCelestialTransactionCelestialsAfterCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockCelestialTransactionCelestialsAfterCall) DoAndReturn(f func(context.Context, string, string, int) ([]storage.Celestial, error)) *MockCelestialTransactionCelestialsAfterCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// CelestialsByIds mocks base method.
+func (m *MockCelestialTransaction) CelestialsByIds(ctx context.Context, network string, ids iter.Seq[string]) ([]storage.Celestial, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CelestialsByIds", ctx, network, ids)
+	ret0, _ := ret[0].([]storage.Celestial)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CelestialsByIds indicates an expected call of CelestialsByIds.
+func (mr *MockCelestialTransactionMockRecorder) CelestialsByIds(ctx, network, ids any) *MockCelestialTransactionCelestialsByIdsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CelestialsByIds", reflect.TypeOf((*MockCelestialTransaction)(nil).CelestialsByIds), ctx, network, ids)
+	return &MockCelestialTransactionCelestialsByIdsCall{Call: call}
+}
+
+// MockCelestialTransactionCelestialsByIdsCall wrap *gomock.Call
+type MockCelestialTransactionCelestialsByIdsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockCelestialTransactionCelestialsByIdsCall) Return(arg0 []storage.Celestial, arg1 error) *MockCelestialTransactionCelestialsByIdsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockCelestialTransactionCelestialsByIdsCall) Do(f func(context.Context, string, iter.Seq[string]) ([]storage.Celestial, error)) *MockCelestialTransactionCelestialsByIdsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockCelestialTransactionCelestialsByIdsCall) DoAndReturn(f func(context.Context, string, iter.Seq[string]) ([]storage.Celestial, error)) *MockCelestialTransactionCelestialsByIdsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Close mocks base method.
 func (m *MockCelestialTransaction) Close(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -158,6 +236,125 @@ func (c *MockCelestialTransactionCloseCall) DoAndReturn(f func(context.Context)
 	return c
 }
 
+// CreateShadow mocks base method.
+func (m *MockCelestialTransaction) CreateShadow(ctx context.Context, network string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateShadow", ctx, network)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateShadow indicates an expected call of CreateShadow.
+func (mr *MockCelestialTransactionMockRecorder) CreateShadow(ctx, network any) *MockCelestialTransactionCreateShadowCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShadow", reflect.TypeOf((*MockCelestialTransaction)(nil).CreateShadow), ctx, network)
+	return &MockCelestialTransactionCreateShadowCall{Call: call}
+}
+
+// MockCelestialTransactionCreateShadowCall wrap *gomock.Call
+type MockCelestialTransactionCreateShadowCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockCelestialTransactionCreateShadowCall) Return(arg0 error) *MockCelestialTransactionCreateShadowCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockCelestialTransactionCreateShadowCall) Do(f func(context.Context, string) error) *MockCelestialTransactionCreateShadowCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockCelestialTransactionCreateShadowCall) DoAndReturn(f func(context.Context, string) error) *MockCelestialTransactionCreateShadowCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// DeleteFailedChanges mocks base method.
+func (m *MockCelestialTransaction) DeleteFailedChanges(ctx context.Context, network string, changeIds ...int64) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, network}
+	for _, a := range changeIds {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteFailedChanges", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFailedChanges indicates an expected call of DeleteFailedChanges.
+func (mr *MockCelestialTransactionMockRecorder) DeleteFailedChanges(ctx, network any, changeIds ...any) *MockCelestialTransactionDeleteFailedChangesCall {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, network}, changeIds...)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFailedChanges", reflect.TypeOf((*MockCelestialTransaction)(nil).DeleteFailedChanges), varargs...)
+	return &MockCelestialTransactionDeleteFailedChangesCall{Call: call}
+}
+
+// MockCelestialTransactionDeleteFailedChangesCall wrap *gomock.Call
+type MockCelestialTransactionDeleteFailedChangesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockCelestialTransactionDeleteFailedChangesCall) Return(arg0 error) *MockCelestialTransactionDeleteFailedChangesCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockCelestialTransactionDeleteFailedChangesCall) Do(f func(context.Context, string, ...int64) error) *MockCelestialTransactionDeleteFailedChangesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockCelestialTransactionDeleteFailedChangesCall) DoAndReturn(f func(context.Context, string, ...int64) error) *MockCelestialTransactionDeleteFailedChangesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// DeleteState mocks base method.
+func (m *MockCelestialTransaction) DeleteState(ctx context.Context, state *storage.CelestialState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteState", ctx, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteState indicates an expected call of DeleteState.
+func (mr *MockCelestialTransactionMockRecorder) DeleteState(ctx, state any) *MockCelestialTransactionDeleteStateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteState", reflect.TypeOf((*MockCelestialTransaction)(nil).DeleteState), ctx, state)
+	return &MockCelestialTransactionDeleteStateCall{Call: call}
+}
+
+// MockCelestialTransactionDeleteStateCall wrap *gomock.Call
+type MockCelestialTransactionDeleteStateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockCelestialTransactionDeleteStateCall) Return(arg0 error) *MockCelestialTransactionDeleteStateCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockCelestialTransactionDeleteStateCall) Do(f func(context.Context, *storage.CelestialState) error) *MockCelestialTransactionDeleteStateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockCelestialTransactionDeleteStateCall) DoAndReturn(f func(context.Context, *storage.CelestialState) error) *MockCelestialTransactionDeleteStateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Exec mocks base method.
 func (m *MockCelestialTransaction) Exec(ctx context.Context, query string, params ...any) (int64, error) {
 	m.ctrl.T.Helper()
@@ -202,6 +399,45 @@ func (c *MockCelestialTransactionExecCall) DoAndReturn(f func(context.Context, s
 	return c
 }
 
+// FailedChanges mocks base method.
+func (m *MockCelestialTransaction) FailedChanges(ctx context.Context, network string, limit int) ([]storage.CelestialFailedChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailedChanges", ctx, network, limit)
+	ret0, _ := ret[0].([]storage.CelestialFailedChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FailedChanges indicates an expected call of FailedChanges.
+func (mr *MockCelestialTransactionMockRecorder) FailedChanges(ctx, network, limit any) *MockCelestialTransactionFailedChangesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailedChanges", reflect.TypeOf((*MockCelestialTransaction)(nil).FailedChanges), ctx, network, limit)
+	return &MockCelestialTransactionFailedChangesCall{Call: call}
+}
+
+// MockCelestialTransactionFailedChangesCall wrap *gomock.Call
+type MockCelestialTransactionFailedChangesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockCelestialTransactionFailedChangesCall) Return(arg0 []storage.CelestialFailedChange, arg1 error) *MockCelestialTransactionFailedChangesCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockCelestialTransactionFailedChangesCall) Do(f func(context.Context, string, int) ([]storage.CelestialFailedChange, error)) *MockCelestialTransactionFailedChangesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockCelestialTransactionFailedChangesCall) DoAndReturn(f func(context.Context, string, int) ([]storage.CelestialFailedChange, error)) *MockCelestialTransactionFailedChangesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Flush mocks base method.
 func (m *MockCelestialTransaction) Flush(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -354,6 +590,46 @@ func (c *MockCelestialTransactionRollbackCall) DoAndReturn(f func(context.Contex
 	return c
 }
 
+// RollbackTo mocks base method.
+func (m *MockCelestialTransaction) RollbackTo(ctx context.Context, state *storage.CelestialState, changeId int64) ([]storage.Celestial, []storage.Celestial, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollbackTo", ctx, state, changeId)
+	ret0, _ := ret[0].([]storage.Celestial)
+	ret1, _ := ret[1].([]storage.Celestial)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RollbackTo indicates an expected call of RollbackTo.
+func (mr *MockCelestialTransactionMockRecorder) RollbackTo(ctx, state, changeId any) *MockCelestialTransactionRollbackToCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackTo", reflect.TypeOf((*MockCelestialTransaction)(nil).RollbackTo), ctx, state, changeId)
+	return &MockCelestialTransactionRollbackToCall{Call: call}
+}
+
+// MockCelestialTransactionRollbackToCall wrap *gomock.Call
+type MockCelestialTransactionRollbackToCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockCelestialTransactionRollbackToCall) Return(before, after []storage.Celestial, err error) *MockCelestialTransactionRollbackToCall {
+	c.Call = c.Call.Return(before, after, err)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockCelestialTransactionRollbackToCall) Do(f func(context.Context, *storage.CelestialState, int64) ([]storage.Celestial, []storage.Celestial, error)) *MockCelestialTransactionRollbackToCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockCelestialTransactionRollbackToCall) DoAndReturn(f func(context.Context, *storage.CelestialState, int64) ([]storage.Celestial, []storage.Celestial, error)) *MockCelestialTransactionRollbackToCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // SaveCelestials mocks base method.
 func (m *MockCelestialTransaction) SaveCelestials(ctx context.Context, celestials iter.Seq[storage.Celestial]) error {
 	m.ctrl.T.Helper()
@@ -392,6 +668,256 @@ func (c *MockCelestialTransactionSaveCelestialsCall) DoAndReturn(f func(context.
 	return c
 }
 
+// SaveFailedChanges mocks base method.
+func (m *MockCelestialTransaction) SaveFailedChanges(ctx context.Context, changes ...storage.CelestialFailedChange) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range changes {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SaveFailedChanges", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveFailedChanges indicates an expected call of SaveFailedChanges.
+func (mr *MockCelestialTransactionMockRecorder) SaveFailedChanges(ctx any, changes ...any) *MockCelestialTransactionSaveFailedChangesCall {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, changes...)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFailedChanges", reflect.TypeOf((*MockCelestialTransaction)(nil).SaveFailedChanges), varargs...)
+	return &MockCelestialTransactionSaveFailedChangesCall{Call: call}
+}
+
+// MockCelestialTransactionSaveFailedChangesCall wrap *gomock.Call
+type MockCelestialTransactionSaveFailedChangesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockCelestialTransactionSaveFailedChangesCall) Return(arg0 error) *MockCelestialTransactionSaveFailedChangesCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockCelestialTransactionSaveFailedChangesCall) Do(f func(context.Context, ...storage.CelestialFailedChange) error) *MockCelestialTransactionSaveFailedChangesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockCelestialTransactionSaveFailedChangesCall) DoAndReturn(f func(context.Context, ...storage.CelestialFailedChange) error) *MockCelestialTransactionSaveFailedChangesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SaveHistory mocks base method.
+func (m *MockCelestialTransaction) SaveHistory(ctx context.Context, history ...storage.CelestialHistory) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range history {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SaveHistory", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveHistory indicates an expected call of SaveHistory.
+func (mr *MockCelestialTransactionMockRecorder) SaveHistory(ctx any, history ...any) *MockCelestialTransactionSaveHistoryCall {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, history...)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveHistory", reflect.TypeOf((*MockCelestialTransaction)(nil).SaveHistory), varargs...)
+	return &MockCelestialTransactionSaveHistoryCall{Call: call}
+}
+
+// MockCelestialTransactionSaveHistoryCall wrap *gomock.Call
+type MockCelestialTransactionSaveHistoryCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockCelestialTransactionSaveHistoryCall) Return(arg0 error) *MockCelestialTransactionSaveHistoryCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockCelestialTransactionSaveHistoryCall) Do(f func(context.Context, ...storage.CelestialHistory) error) *MockCelestialTransactionSaveHistoryCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockCelestialTransactionSaveHistoryCall) DoAndReturn(f func(context.Context, ...storage.CelestialHistory) error) *MockCelestialTransactionSaveHistoryCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SaveNewFailedChanges mocks base method.
+func (m *MockCelestialTransaction) SaveNewFailedChanges(ctx context.Context, changes ...storage.CelestialFailedChange) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range changes {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SaveNewFailedChanges", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveNewFailedChanges indicates an expected call of SaveNewFailedChanges.
+func (mr *MockCelestialTransactionMockRecorder) SaveNewFailedChanges(ctx any, changes ...any) *MockCelestialTransactionSaveNewFailedChangesCall {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, changes...)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveNewFailedChanges", reflect.TypeOf((*MockCelestialTransaction)(nil).SaveNewFailedChanges), varargs...)
+	return &MockCelestialTransactionSaveNewFailedChangesCall{Call: call}
+}
+
+// MockCelestialTransactionSaveNewFailedChangesCall wrap *gomock.Call
+type MockCelestialTransactionSaveNewFailedChangesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockCelestialTransactionSaveNewFailedChangesCall) Return(arg0 error) *MockCelestialTransactionSaveNewFailedChangesCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockCelestialTransactionSaveNewFailedChangesCall) Do(f func(context.Context, ...storage.CelestialFailedChange) error) *MockCelestialTransactionSaveNewFailedChangesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockCelestialTransactionSaveNewFailedChangesCall) DoAndReturn(f func(context.Context, ...storage.CelestialFailedChange) error) *MockCelestialTransactionSaveNewFailedChangesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SaveShadowCelestials mocks base method.
+func (m *MockCelestialTransaction) SaveShadowCelestials(ctx context.Context, celestials iter.Seq[storage.Celestial]) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveShadowCelestials", ctx, celestials)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveShadowCelestials indicates an expected call of SaveShadowCelestials.
+func (mr *MockCelestialTransactionMockRecorder) SaveShadowCelestials(ctx, celestials any) *MockCelestialTransactionSaveShadowCelestialsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveShadowCelestials", reflect.TypeOf((*MockCelestialTransaction)(nil).SaveShadowCelestials), ctx, celestials)
+	return &MockCelestialTransactionSaveShadowCelestialsCall{Call: call}
+}
+
+// MockCelestialTransactionSaveShadowCelestialsCall wrap *gomock.Call
+type MockCelestialTransactionSaveShadowCelestialsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockCelestialTransactionSaveShadowCelestialsCall) Return(arg0 error) *MockCelestialTransactionSaveShadowCelestialsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockCelestialTransactionSaveShadowCelestialsCall) Do(f func(context.Context, iter.Seq[storage.Celestial]) error) *MockCelestialTransactionSaveShadowCelestialsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockCelestialTransactionSaveShadowCelestialsCall) DoAndReturn(f func(context.Context, iter.Seq[storage.Celestial]) error) *MockCelestialTransactionSaveShadowCelestialsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SaveShadowHistory mocks base method.
+func (m *MockCelestialTransaction) SaveShadowHistory(ctx context.Context, history ...storage.CelestialHistory) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range history {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SaveShadowHistory", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveShadowHistory indicates an expected call of SaveShadowHistory.
+func (mr *MockCelestialTransactionMockRecorder) SaveShadowHistory(ctx any, history ...any) *MockCelestialTransactionSaveShadowHistoryCall {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, history...)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveShadowHistory", reflect.TypeOf((*MockCelestialTransaction)(nil).SaveShadowHistory), varargs...)
+	return &MockCelestialTransactionSaveShadowHistoryCall{Call: call}
+}
+
+// MockCelestialTransactionSaveShadowHistoryCall wrap *gomock.Call
+type MockCelestialTransactionSaveShadowHistoryCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockCelestialTransactionSaveShadowHistoryCall) Return(arg0 error) *MockCelestialTransactionSaveShadowHistoryCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockCelestialTransactionSaveShadowHistoryCall) Do(f func(context.Context, ...storage.CelestialHistory) error) *MockCelestialTransactionSaveShadowHistoryCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockCelestialTransactionSaveShadowHistoryCall) DoAndReturn(f func(context.Context, ...storage.CelestialHistory) error) *MockCelestialTransactionSaveShadowHistoryCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SwapShadow mocks base method.
+func (m *MockCelestialTransaction) SwapShadow(ctx context.Context, network string) ([]storage.Celestial, []storage.Celestial, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SwapShadow", ctx, network)
+	ret0, _ := ret[0].([]storage.Celestial)
+	ret1, _ := ret[1].([]storage.Celestial)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SwapShadow indicates an expected call of SwapShadow.
+func (mr *MockCelestialTransactionMockRecorder) SwapShadow(ctx, network any) *MockCelestialTransactionSwapShadowCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SwapShadow", reflect.TypeOf((*MockCelestialTransaction)(nil).SwapShadow), ctx, network)
+	return &MockCelestialTransactionSwapShadowCall{Call: call}
+}
+
+// MockCelestialTransactionSwapShadowCall wrap *gomock.Call
+type MockCelestialTransactionSwapShadowCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockCelestialTransactionSwapShadowCall) Return(before, after []storage.Celestial, err error) *MockCelestialTransactionSwapShadowCall {
+	c.Call = c.Call.Return(before, after, err)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockCelestialTransactionSwapShadowCall) Do(f func(context.Context, string) ([]storage.Celestial, []storage.Celestial, error)) *MockCelestialTransactionSwapShadowCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockCelestialTransactionSwapShadowCall) DoAndReturn(f func(context.Context, string) ([]storage.Celestial, []storage.Celestial, error)) *MockCelestialTransactionSwapShadowCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Tx mocks base method.
 func (m *MockCelestialTransaction) Tx() *bun.Tx {
 	m.ctrl.T.Helper()
@@ -468,6 +994,45 @@ func (c *MockCelestialTransactionUpdateCall) DoAndReturn(f func(context.Context,
 	return c
 }
 
+// UpdateShadowStatusForAddress mocks base method.
+func (m *MockCelestialTransaction) UpdateShadowStatusForAddress(ctx context.Context, network string, primaries map[uint64]string) ([]storage.Celestial, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateShadowStatusForAddress", ctx, network, primaries)
+	ret0, _ := ret[0].([]storage.Celestial)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateShadowStatusForAddress indicates an expected call of UpdateShadowStatusForAddress.
+func (mr *MockCelestialTransactionMockRecorder) UpdateShadowStatusForAddress(ctx, network, primaries any) *MockCelestialTransactionUpdateShadowStatusForAddressCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateShadowStatusForAddress", reflect.TypeOf((*MockCelestialTransaction)(nil).UpdateShadowStatusForAddress), ctx, network, primaries)
+	return &MockCelestialTransactionUpdateShadowStatusForAddressCall{Call: call}
+}
+
+// MockCelestialTransactionUpdateShadowStatusForAddressCall wrap *gomock.Call
+type MockCelestialTransactionUpdateShadowStatusForAddressCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockCelestialTransactionUpdateShadowStatusForAddressCall) Return(arg0 []storage.Celestial, arg1 error) *MockCelestialTransactionUpdateShadowStatusForAddressCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockCelestialTransactionUpdateShadowStatusForAddressCall) Do(f func(context.Context, string, map[uint64]string) ([]storage.Celestial, error)) *MockCelestialTransactionUpdateShadowStatusForAddressCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockCelestialTransactionUpdateShadowStatusForAddressCall) DoAndReturn(f func(context.Context, string, map[uint64]string) ([]storage.Celestial, error)) *MockCelestialTransactionUpdateShadowStatusForAddressCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // UpdateState mocks base method.
 func (m *MockCelestialTransaction) UpdateState(ctx context.Context, state *storage.CelestialState) error {
 	m.ctrl.T.Helper()
@@ -507,22 +1072,18 @@ func (c *MockCelestialTransactionUpdateStateCall) DoAndReturn(f func(context.Con
 }
 
 // UpdateStatusForAddress mocks base method.
-func (m *MockCelestialTransaction) UpdateStatusForAddress(ctx context.Context, addressId ...iter.Seq[uint64]) error {
+func (m *MockCelestialTransaction) UpdateStatusForAddress(ctx context.Context, network string, primaries map[uint64]string) ([]storage.Celestial, error) {
 	m.ctrl.T.Helper()
-	varargs := []any{ctx}
-	for _, a := range addressId {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "UpdateStatusForAddress", varargs...)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "UpdateStatusForAddress", ctx, network, primaries)
+	ret0, _ := ret[0].([]storage.Celestial)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // UpdateStatusForAddress indicates an expected call of UpdateStatusForAddress.
-func (mr *MockCelestialTransactionMockRecorder) UpdateStatusForAddress(ctx any, addressId ...any) *MockCelestialTransactionUpdateStatusForAddressCall {
+func (mr *MockCelestialTransactionMockRecorder) UpdateStatusForAddress(ctx, network, primaries any) *MockCelestialTransactionUpdateStatusForAddressCall {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]any{ctx}, addressId...)
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatusForAddress", reflect.TypeOf((*MockCelestialTransaction)(nil).UpdateStatusForAddress), varargs...)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatusForAddress", reflect.TypeOf((*MockCelestialTransaction)(nil).UpdateStatusForAddress), ctx, network, primaries)
 	return &MockCelestialTransactionUpdateStatusForAddressCall{Call: call}
 }
 
@@ -532,19 +1093,19 @@ type MockCelestialTransactionUpdateStatusForAddressCall struct {
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockCelestialTransactionUpdateStatusForAddressCall) Return(arg0 error) *MockCelestialTransactionUpdateStatusForAddressCall {
-	c.Call = c.Call.Return(arg0)
+func (c *MockCelestialTransactionUpdateStatusForAddressCall) Return(arg0 []storage.Celestial, arg1 error) *MockCelestialTransactionUpdateStatusForAddressCall {
+	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockCelestialTransactionUpdateStatusForAddressCall) Do(f func(context.Context, ...iter.Seq[uint64]) error) *MockCelestialTransactionUpdateStatusForAddressCall {
+func (c *MockCelestialTransactionUpdateStatusForAddressCall) Do(f func(context.Context, string, map[uint64]string) ([]storage.Celestial, error)) *MockCelestialTransactionUpdateStatusForAddressCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockCelestialTransactionUpdateStatusForAddressCall) DoAndReturn(f func(context.Context, ...iter.Seq[uint64]) error) *MockCelestialTransactionUpdateStatusForAddressCall {
+func (c *MockCelestialTransactionUpdateStatusForAddressCall) DoAndReturn(f func(context.Context, string, map[uint64]string) ([]storage.Celestial, error)) *MockCelestialTransactionUpdateStatusForAddressCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
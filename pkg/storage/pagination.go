@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MaxLimit - max count of items returned by list queries
+const MaxLimit = 100
+
+var (
+	// ErrInvalidLimit - returned by list queries if limit is not in range [1, MaxLimit]
+	ErrInvalidLimit = errors.New("invalid limit")
+	// ErrInvalidOffset - returned by search queries if offset is negative
+	ErrInvalidOffset = errors.New("invalid offset")
+	// ErrInvalidCursor - returned by list queries if cursor can't be decoded
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidSort - returned by list queries if sort order or sort field is unknown
+	ErrInvalidSort = errors.New("invalid sort order")
+)
+
+// ValidateLimit - returns ErrInvalidLimit if limit is not in range [1, MaxLimit]
+func ValidateLimit(limit int) error {
+	if limit < 1 || limit > MaxLimit {
+		return errors.Wrapf(ErrInvalidLimit, "%d is out of range [1, %d]", limit, MaxLimit)
+	}
+	return nil
+}
+
+// ValidateOffset - returns ErrInvalidOffset if offset is negative
+func ValidateOffset(offset int) error {
+	if offset < 0 {
+		return errors.Wrapf(ErrInvalidOffset, "%d is negative", offset)
+	}
+	return nil
+}
+
+// SortOrder - direction of sorting in list queries
+type SortOrder string
+
+const (
+	SortDesc SortOrder = "desc"
+	SortAsc  SortOrder = "asc"
+)
+
+// SortField - column which list is sorted by. Ties are broken by celestial id.
+type SortField string
+
+const (
+	SortByChangeId        SortField = "change_id"
+	SortByCreatedAt       SortField = "created_at"
+	SortByUpdatedAt       SortField = "updated_at"
+	SortByStatusChangedAt SortField = "status_changed_at"
+)
+
+// key - returns value of the sort field of celestial id. Timestamps are represented by unix microseconds
+// which is the precision of postgres timestamps.
+func (f SortField) key(cid Celestial) int64 {
+	switch f {
+	case SortByCreatedAt:
+		return cid.CreatedAt.UnixMicro()
+	case SortByUpdatedAt:
+		return cid.UpdatedAt.UnixMicro()
+	case SortByStatusChangedAt:
+		return cid.StatusChangedAt.UnixMicro()
+	default:
+		return cid.ChangeId
+	}
+}
+
+// Cursor - opaque position in list ordered by sort field and celestial id. Empty cursor points to the first page.
+type Cursor string
+
+// NewCursor - returns cursor pointing after the passed item of list sorted by the field
+func NewCursor(field SortField, cid Celestial) Cursor {
+	raw := string(field) + ":" + strconv.FormatInt(field.key(cid), 10) + ":" + cid.Id
+	return Cursor(base64.RawURLEncoding.EncodeToString([]byte(raw)))
+}
+
+// Decode - returns sort key and celestial id of the item which cursor points after. Sort key is change id
+// or unix microseconds of timestamp. It returns ErrInvalidCursor if cursor was created for other sort field.
+func (c Cursor) Decode(field SortField) (key int64, id string, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(string(c))
+	if err != nil {
+		return 0, "", errors.Wrap(ErrInvalidCursor, err.Error())
+	}
+	parts := strings.SplitN(string(raw), ":", 3)
+	if len(parts) != 3 {
+		return 0, "", ErrInvalidCursor
+	}
+	if SortField(parts[0]) != field {
+		return 0, "", errors.Wrapf(ErrInvalidCursor, "cursor of %s sorting", parts[0])
+	}
+	key, err = strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, "", errors.Wrap(ErrInvalidCursor, err.Error())
+	}
+	return key, parts[2], nil
+}
+
+// ListOptions - options of list queries with keyset pagination by sort field and celestial id
+type ListOptions struct {
+	// Limit - count of items in page. It should be in range [1, MaxLimit].
+	Limit int
+	// Cursor - position returned with the previous page. Empty cursor means the first page.
+	Cursor Cursor
+	// Sort - sort order. Default is SortDesc.
+	Sort SortOrder
+	// SortBy - sort field. Default is SortByChangeId.
+	SortBy SortField
+	// Status - returns only celestial ids with the passed statuses if it's not empty
+	Status []Status
+}
+
+// OrderBy - returns sort field with applied default
+func (opts ListOptions) OrderBy() SortField {
+	if opts.SortBy == "" {
+		return SortByChangeId
+	}
+	return opts.SortBy
+}
+
+// Validate - checks limit, sort order and sort field
+func (opts ListOptions) Validate() error {
+	if err := ValidateLimit(opts.Limit); err != nil {
+		return err
+	}
+	switch opts.SortBy {
+	case "", SortByChangeId, SortByCreatedAt, SortByUpdatedAt, SortByStatusChangedAt:
+	default:
+		return errors.Wrap(ErrInvalidSort, string(opts.SortBy))
+	}
+	switch opts.Sort {
+	case "", SortDesc, SortAsc:
+		return nil
+	default:
+		return errors.Wrap(ErrInvalidSort, string(opts.Sort))
+	}
+}
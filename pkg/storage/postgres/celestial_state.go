@@ -17,10 +17,11 @@ func NewCelestialState(db *database.Bun) *CelestialState {
 	}
 }
 
-func (cs *CelestialState) ByName(ctx context.Context, name string) (result storage.CelestialState, err error) {
+func (cs *CelestialState) ByName(ctx context.Context, name, network string) (result storage.CelestialState, err error) {
 	err = cs.db.DB().NewSelect().
 		Model(&result).
 		Where("name = ?", name).
+		Where("network = ?", network).
 		Limit(1).
 		Scan(ctx)
 	return
@@ -2,9 +2,11 @@ package postgres
 
 import (
 	"context"
+	"strings"
 
 	"github.com/celenium-io/celestial-module/pkg/storage"
 	"github.com/dipdup-io/go-lib/database"
+	"github.com/uptrace/bun"
 )
 
 type Celestials struct {
@@ -17,35 +19,120 @@ func NewCelestials(db *database.Bun) *Celestials {
 	}
 }
 
-func (c *Celestials) ById(ctx context.Context, id string) (result storage.Celestial, err error) {
+func (c *Celestials) ById(ctx context.Context, network, id string) (result storage.Celestial, err error) {
 	err = c.DB().NewSelect().
 		Model(&result).
+		Where("network = ?", network).
 		Where("id = ?", id).
 		Limit(1).
 		Scan(ctx)
 	return
 }
 
-func (c *Celestials) ByAddressId(ctx context.Context, addressId uint64, limit, offset int) (result []storage.Celestial, err error) {
-	query := c.DB().NewSelect().
-		Model(&result).
-		Where("address_id = ?", addressId).
-		Offset(offset).
-		OrderExpr("change_id desc")
+// ByAddressId - returns page of celestial ids connected to address and cursor of the next page.
+// Cursor is empty for the last page.
+func (c *Celestials) ByAddressId(ctx context.Context, network string, addressId uint64, opts storage.ListOptions) ([]storage.Celestial, storage.Cursor, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, "", err
+	}
 
-	if limit < 0 || limit > 100 {
-		limit = 10
+	var result []storage.Celestial
+	query, err := keyset(
+		c.DB().NewSelect().
+			Model(&result).
+			Where("network = ?", network).
+			Where("address_id = ?", addressId),
+		opts,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := query.Scan(ctx); err != nil {
+		return nil, "", err
 	}
 
-	err = query.Limit(limit).Scan(ctx)
-	return
+	result, next := nextPage(result, opts)
+	return result, next, nil
 }
 
-func (c *Celestials) Primary(ctx context.Context, addressId uint64) (result storage.Celestial, err error) {
+func (c *Celestials) Primary(ctx context.Context, network string, addressId uint64) (result storage.Celestial, err error) {
 	err = c.DB().NewSelect().
 		Model(&result).
+		Where("network = ?", network).
 		Where("address_id = ?", addressId).
 		Where("status = ?", storage.StatusPRIMARY).
 		Scan(ctx)
 	return
 }
+
+func (c *Celestials) ByIds(ctx context.Context, network string, ids []string) (result []storage.Celestial, err error) {
+	if len(ids) == 0 {
+		return []storage.Celestial{}, nil
+	}
+	err = c.DB().NewSelect().
+		Model(&result).
+		Where("network = ?", network).
+		Where("id IN (?)", bun.In(ids)).
+		Scan(ctx)
+	return
+}
+
+func (c *Celestials) PrimaryByAddressIds(ctx context.Context, network string, addressIds []uint64) (map[uint64]storage.Celestial, error) {
+	result := make(map[uint64]storage.Celestial, len(addressIds))
+	if len(addressIds) == 0 {
+		return result, nil
+	}
+
+	var primaries []storage.Celestial
+	if err := c.DB().NewSelect().
+		Model(&primaries).
+		Where("network = ?", network).
+		Where("address_id IN (?)", bun.In(addressIds)).
+		Where("status = ?", storage.StatusPRIMARY).
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	for i := range primaries {
+		result[primaries[i].AddressId] = primaries[i]
+	}
+	return result, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Search - returns celestial ids which contain the query or are similar to it by trigram similarity.
+// Exact match goes first, then primary celestial ids, prefix matches and the most similar ones.
+func (c *Celestials) Search(ctx context.Context, network, query string, limit, offset int, status ...storage.Status) (result []storage.Celestial, err error) {
+	if err := storage.ValidateLimit(limit); err != nil {
+		return nil, err
+	}
+	if err := storage.ValidateOffset(offset); err != nil {
+		return nil, err
+	}
+	if query == "" {
+		return []storage.Celestial{}, nil
+	}
+	escaped := likeEscaper.Replace(query)
+
+	q := c.DB().NewSelect().
+		Model(&result).
+		Where("network = ?", network).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("id ILIKE ?", "%"+escaped+"%").WhereOr("id % ?", query)
+		})
+	if len(status) > 0 {
+		q = q.Where("status IN (?)", bun.In(status))
+	}
+
+	err = q.
+		OrderExpr("lower(id) = lower(?) DESC", query).
+		OrderExpr("status = ? DESC", storage.StatusPRIMARY).
+		OrderExpr("id ILIKE ? DESC", escaped+"%").
+		OrderExpr("similarity(id, ?) DESC", query).
+		OrderExpr("id ASC").
+		Offset(offset).
+		Limit(limit).
+		Scan(ctx)
+	return
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/celenium-io/celestial-module/pkg/storage"
+)
+
+// saveCelestialsOneByOne - previous implementation of SaveCelestials which is used as baseline
+func saveCelestialsOneByOne(ctx context.Context, tx CelestialTransaction, celestials iter.Seq[storage.Celestial]) error {
+	for cel := range celestials {
+		_, err := tx.Tx().NewInsert().
+			Model(&cel).
+			Column("id", "network", "address_id", "image_url", "change_id", "status").
+			On("CONFLICT (id, network) DO UPDATE").
+			Set("address_id = EXCLUDED.address_id").
+			Set("image_url = EXCLUDED.image_url").
+			Set("change_id = EXCLUDED.change_id").
+			Set("status = EXCLUDED.status").
+			Where("celestial.change_id <= EXCLUDED.change_id").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func BenchmarkSaveCelestials(b *testing.B) {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer ctxCancel()
+
+	psqlContainer, strg, err := newTestStorage(ctx)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		_ = strg.Close()
+		_ = psqlContainer.Terminate(context.Background())
+	})
+
+	for _, size := range []int{100, 500, 2500} {
+		for _, impl := range []struct {
+			name string
+			save func(ctx context.Context, tx CelestialTransaction, celestials iter.Seq[storage.Celestial]) error
+		}{
+			{"one_by_one", saveCelestialsOneByOne},
+			{"bulk", func(ctx context.Context, tx CelestialTransaction, celestials iter.Seq[storage.Celestial]) error {
+				return tx.SaveCelestials(ctx, celestials)
+			}},
+		} {
+			b.Run(fmt.Sprintf("%s/%d", impl.name, size), func(b *testing.B) {
+				var changeId int64
+				for b.Loop() {
+					changeId++
+					celestials := make([]storage.Celestial, size)
+					for i := range celestials {
+						celestials[i] = storage.Celestial{
+							Id:        fmt.Sprintf("bench %d", i),
+							Network:   testNetwork,
+							AddressId: uint64(i),
+							ImageUrl:  "image_url",
+							ChangeId:  changeId,
+							Status:    storage.StatusVERIFIED,
+						}
+					}
+
+					tx, err := BeginCelestialTransaction(ctx, strg.Transactable)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if err := impl.save(ctx, tx, slices.Values(celestials)); err != nil {
+						b.Fatal(err)
+					}
+					if err := tx.Flush(ctx); err != nil {
+						b.Fatal(err)
+					}
+					if err := tx.Close(ctx); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -13,8 +13,11 @@ import (
 	"github.com/dipdup-net/indexer-sdk/pkg/storage/postgres"
 	"github.com/go-testfixtures/testfixtures/v3"
 	"github.com/stretchr/testify/suite"
+	"github.com/uptrace/bun"
 )
 
+const testNetwork = "celestia"
+
 // CelestialsTestSuite -
 type CelestialsTestSuite struct {
 	suite.Suite
@@ -23,13 +26,12 @@ type CelestialsTestSuite struct {
 
 	celestials     *Celestials
 	celestialState *CelestialState
+	failedChanges  *CelestialFailedChanges
+	history        *CelestialHistory
 }
 
-// SetupSuite -
-func (s *CelestialsTestSuite) SetupSuite() {
-	ctx, ctxCancel := context.WithTimeout(context.Background(), 90*time.Second)
-	defer ctxCancel()
-
+// newTestStorage - starts postgres container and creates tables
+func newTestStorage(ctx context.Context) (*database.PostgreSQLContainer, *postgres.Storage, error) {
 	psqlContainer, err := database.NewPostgreSQLContainer(ctx, database.PostgreSQLContainerConfig{
 		User:     "user",
 		Password: "password",
@@ -37,35 +39,56 @@ func (s *CelestialsTestSuite) SetupSuite() {
 		Port:     5432,
 		Image:    "timescale/timescaledb-ha:pg15.8-ts2.17.0-all",
 	})
-	s.Require().NoError(err)
-	s.psqlContainer = psqlContainer
+	if err != nil {
+		return nil, nil, err
+	}
 
 	init := func(ctx context.Context, conn *database.Bun) error {
 		if err := CreateTypes(ctx, conn); err != nil {
 			return err
 		}
-		if err := database.CreateTables(ctx, conn, new(storage.Celestial), new(storage.CelestialState)); err != nil {
+		if err := database.CreateTables(ctx, conn, new(storage.Celestial), new(storage.CelestialState), new(storage.CelestialFailedChange), new(storage.CelestialHistory)); err != nil {
 			if err := conn.Close(); err != nil {
 				return err
 			}
 			return err
 		}
-		return nil
+		if err := CreateHypertables(ctx, conn); err != nil {
+			return err
+		}
+		return conn.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			return CreateIndex(ctx, tx)
+		})
 	}
 
 	strg, err := postgres.Create(ctx, config.Database{
 		Kind:     config.DBKindPostgres,
-		User:     s.psqlContainer.Config.User,
-		Database: s.psqlContainer.Config.Database,
-		Password: s.psqlContainer.Config.Password,
-		Host:     s.psqlContainer.Config.Host,
-		Port:     s.psqlContainer.MappedPort().Int(),
+		User:     psqlContainer.Config.User,
+		Database: psqlContainer.Config.Database,
+		Password: psqlContainer.Config.Password,
+		Host:     psqlContainer.Config.Host,
+		Port:     psqlContainer.MappedPort().Int(),
 	}, init)
+	if err != nil {
+		return psqlContainer, nil, err
+	}
+	return psqlContainer, strg, nil
+}
+
+// SetupSuite -
+func (s *CelestialsTestSuite) SetupSuite() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer ctxCancel()
+
+	psqlContainer, strg, err := newTestStorage(ctx)
 	s.Require().NoError(err)
+	s.psqlContainer = psqlContainer
 	s.storage = strg
 
 	s.celestials = NewCelestials(strg.Connection())
 	s.celestialState = NewCelestialState(strg.Connection())
+	s.failedChanges = NewCelestialFailedChanges(strg.Connection())
+	s.history = NewCelestialHistory(strg.Connection())
 
 	db, err := sql.Open("pgx", s.psqlContainer.GetDSN())
 	s.Require().NoError(err)
@@ -98,7 +121,7 @@ func (s *CelestialsTestSuite) TestCelestialsById() {
 	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer ctxCancel()
 
-	item, err := s.celestials.ById(ctx, "name 3")
+	item, err := s.celestials.ById(ctx, testNetwork, "name 3")
 	s.Require().NoError(err)
 	s.Require().EqualValues("", item.ImageUrl)
 	s.Require().EqualValues("name 3", item.Id)
@@ -106,13 +129,29 @@ func (s *CelestialsTestSuite) TestCelestialsById() {
 	s.Require().EqualValues(2, item.AddressId)
 }
 
+func (s *CelestialsTestSuite) TestCelestialsOtherNetwork() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	_, err := s.celestials.ById(ctx, "mocha-4", "name 3")
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+
+	items, _, err := s.celestials.ByAddressId(ctx, "mocha-4", 1, storage.ListOptions{Limit: 10})
+	s.Require().NoError(err)
+	s.Require().Len(items, 0)
+
+	_, err = s.celestialState.ByName(ctx, "indexer", "mocha-4")
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+}
+
 func (s *CelestialsTestSuite) TestCelestialsByAddressId() {
 	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer ctxCancel()
 
-	items, err := s.celestials.ByAddressId(ctx, 1, 10, 0)
+	items, next, err := s.celestials.ByAddressId(ctx, testNetwork, 1, storage.ListOptions{Limit: 10})
 	s.Require().NoError(err)
 	s.Require().Len(items, 2)
+	s.Require().Empty(next)
 
 	item := items[0]
 	s.Require().EqualValues("", item.ImageUrl)
@@ -121,11 +160,82 @@ func (s *CelestialsTestSuite) TestCelestialsByAddressId() {
 	s.Require().EqualValues(1, item.AddressId)
 }
 
+func (s *CelestialsTestSuite) TestCelestialsByAddressIdCursor() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	items, next, err := s.celestials.ByAddressId(ctx, testNetwork, 21, storage.ListOptions{Limit: 1})
+	s.Require().NoError(err)
+	s.Require().Len(items, 1)
+	s.Require().Equal("travel 3", items[0].Id)
+	s.Require().NotEmpty(next)
+
+	items, next, err = s.celestials.ByAddressId(ctx, testNetwork, 21, storage.ListOptions{Limit: 1, Cursor: next})
+	s.Require().NoError(err)
+	s.Require().Len(items, 1)
+	s.Require().Equal("travel 1", items[0].Id)
+	s.Require().Empty(next)
+
+	items, _, err = s.celestials.ByAddressId(ctx, testNetwork, 21, storage.ListOptions{Limit: 10, Sort: storage.SortAsc})
+	s.Require().NoError(err)
+	s.Require().Len(items, 2)
+	s.Require().Equal("travel 1", items[0].Id)
+
+	items, _, err = s.celestials.ByAddressId(ctx, testNetwork, 21, storage.ListOptions{Limit: 10, Status: []storage.Status{storage.StatusVERIFIED}})
+	s.Require().NoError(err)
+	s.Require().Len(items, 1)
+	s.Require().Equal("travel 1", items[0].Id)
+
+	_, _, err = s.celestials.ByAddressId(ctx, testNetwork, 21, storage.ListOptions{Limit: 101})
+	s.Require().ErrorIs(err, storage.ErrInvalidLimit)
+	_, _, err = s.celestials.ByAddressId(ctx, testNetwork, 21, storage.ListOptions{})
+	s.Require().ErrorIs(err, storage.ErrInvalidLimit)
+	_, _, err = s.celestials.ByAddressId(ctx, testNetwork, 21, storage.ListOptions{Limit: 10, Cursor: "!"})
+	s.Require().ErrorIs(err, storage.ErrInvalidCursor)
+	_, _, err = s.celestials.ByAddressId(ctx, testNetwork, 21, storage.ListOptions{Limit: 10, Sort: "up"})
+	s.Require().ErrorIs(err, storage.ErrInvalidSort)
+	_, _, err = s.celestials.ByAddressId(ctx, testNetwork, 21, storage.ListOptions{Limit: 10, SortBy: "name"})
+	s.Require().ErrorIs(err, storage.ErrInvalidSort)
+
+	_, err = s.history.ById(ctx, testNetwork, "travel 1", 0, 0)
+	s.Require().ErrorIs(err, storage.ErrInvalidLimit)
+}
+
+func (s *CelestialsTestSuite) TestCelestialsByAddressIdSortByTimestamps() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	items, next, err := s.celestials.ByAddressId(ctx, testNetwork, 21, storage.ListOptions{Limit: 1, SortBy: storage.SortByCreatedAt})
+	s.Require().NoError(err)
+	s.Require().Len(items, 1)
+	s.Require().Equal("travel 1", items[0].Id)
+	s.Require().Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), items[0].CreatedAt.UTC())
+	s.Require().NotEmpty(next)
+
+	items, next, err = s.celestials.ByAddressId(ctx, testNetwork, 21, storage.ListOptions{Limit: 1, SortBy: storage.SortByCreatedAt, Cursor: next})
+	s.Require().NoError(err)
+	s.Require().Len(items, 1)
+	s.Require().Equal("travel 3", items[0].Id)
+	s.Require().Equal(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), items[0].UpdatedAt.UTC())
+	s.Require().Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), items[0].StatusChangedAt.UTC())
+	s.Require().Empty(next)
+
+	items, _, err = s.celestials.ByAddressId(ctx, testNetwork, 21, storage.ListOptions{Limit: 10, SortBy: storage.SortByUpdatedAt, Sort: storage.SortAsc})
+	s.Require().NoError(err)
+	s.Require().Len(items, 2)
+	s.Require().Equal("travel 1", items[0].Id)
+
+	_, cursor, err := s.celestials.ByAddressId(ctx, testNetwork, 21, storage.ListOptions{Limit: 1})
+	s.Require().NoError(err)
+	_, _, err = s.celestials.ByAddressId(ctx, testNetwork, 21, storage.ListOptions{Limit: 1, SortBy: storage.SortByCreatedAt, Cursor: cursor})
+	s.Require().ErrorIs(err, storage.ErrInvalidCursor)
+}
+
 func (s *CelestialsTestSuite) TestCelestialsPrimary() {
 	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer ctxCancel()
 
-	item, err := s.celestials.Primary(ctx, 1)
+	item, err := s.celestials.Primary(ctx, testNetwork, 1)
 	s.Require().NoError(err)
 
 	s.Require().EqualValues("", item.ImageUrl)
@@ -134,6 +244,87 @@ func (s *CelestialsTestSuite) TestCelestialsPrimary() {
 	s.Require().EqualValues(1, item.AddressId)
 }
 
+func (s *CelestialsTestSuite) TestCelestialsByIds() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	items, err := s.celestials.ByIds(ctx, testNetwork, []string{"travel 1", "travel 2", "unknown"})
+	s.Require().NoError(err)
+	s.Require().Len(items, 2)
+	for i := range items {
+		s.Require().Contains([]string{"travel 1", "travel 2"}, items[i].Id)
+		s.Require().Equal(testNetwork, items[i].Network)
+	}
+
+	items, err = s.celestials.ByIds(ctx, testNetwork, nil)
+	s.Require().NoError(err)
+	s.Require().Empty(items)
+
+	items, err = s.celestials.ByIds(ctx, "other", []string{"travel 1"})
+	s.Require().NoError(err)
+	s.Require().Empty(items)
+}
+
+func (s *CelestialsTestSuite) TestCelestialsPrimaryByAddressIds() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	primaries, err := s.celestials.PrimaryByAddressIds(ctx, testNetwork, []uint64{20, 21, 100})
+	s.Require().NoError(err)
+	s.Require().Len(primaries, 2)
+	s.Require().Equal("travel 2", primaries[20].Id)
+	s.Require().Equal("travel 3", primaries[21].Id)
+	s.Require().NotContains(primaries, uint64(100))
+
+	primaries, err = s.celestials.PrimaryByAddressIds(ctx, testNetwork, nil)
+	s.Require().NoError(err)
+	s.Require().Empty(primaries)
+}
+
+func (s *CelestialsTestSuite) TestCelestialsSearch() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	ids := func(items []storage.Celestial) []string {
+		result := make([]string, len(items))
+		for i := range items {
+			result[i] = items[i].Id
+		}
+		return result
+	}
+
+	items, err := s.celestials.Search(ctx, testNetwork, "trav", 10, 0)
+	s.Require().NoError(err)
+	s.Require().Equal([]string{"travel 2", "travel 3", "travel 1"}, ids(items))
+
+	items, err = s.celestials.Search(ctx, testNetwork, "travel 1", 10, 0)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(items)
+	s.Require().Equal("travel 1", items[0].Id)
+
+	items, err = s.celestials.Search(ctx, testNetwork, "avel", 1, 1)
+	s.Require().NoError(err)
+	s.Require().Equal([]string{"travel 3"}, ids(items))
+
+	items, err = s.celestials.Search(ctx, testNetwork, "travl", 10, 0, storage.StatusVERIFIED)
+	s.Require().NoError(err)
+	s.Require().Equal([]string{"travel 1"}, ids(items))
+
+	items, err = s.celestials.Search(ctx, testNetwork, "%", 10, 0)
+	s.Require().NoError(err)
+	s.Require().Empty(items)
+
+	items, err = s.celestials.Search(ctx, "other", "travel", 10, 0)
+	s.Require().NoError(err)
+	s.Require().Empty(items)
+
+	_, err = s.celestials.Search(ctx, testNetwork, "travel", 0, 0)
+	s.Require().ErrorIs(err, storage.ErrInvalidLimit)
+
+	_, err = s.celestials.Search(ctx, testNetwork, "travel", 10, -1)
+	s.Require().ErrorIs(err, storage.ErrInvalidOffset)
+}
+
 func (s *CelestialsTestSuite) TestCelestialsTransaction() {
 	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer ctxCancel()
@@ -141,17 +332,19 @@ func (s *CelestialsTestSuite) TestCelestialsTransaction() {
 	tx, err := BeginCelestialTransaction(ctx, s.storage.Transactable)
 	s.Require().NoError(err)
 
-	state, err := s.celestialState.ByName(ctx, "indexer")
+	state, err := s.celestialState.ByName(ctx, "indexer", testNetwork)
 	s.Require().NoError(err)
 
 	celIds := []storage.Celestial{
 		{
+			Network:   testNetwork,
 			Id:        "name 3",
 			AddressId: 1,
 			ChangeId:  4,
 			ImageUrl:  "image_url",
 			Status:    storage.StatusPRIMARY,
 		}, {
+			Network:   testNetwork,
 			Id:        "name 4",
 			AddressId: 3,
 			ChangeId:  5,
@@ -161,8 +354,11 @@ func (s *CelestialsTestSuite) TestCelestialsTransaction() {
 	}
 	state.ChangeId = celIds[1].ChangeId
 
-	err = tx.UpdateStatusForAddress(ctx, slices.Values([]uint64{1, 3}))
+	revoked, err := tx.UpdateStatusForAddress(ctx, testNetwork, map[uint64]string{1: "name 3", 3: "name 4"})
 	s.Require().NoError(err)
+	s.Require().Len(revoked, 1)
+	s.Require().Equal("name 1", revoked[0].Id)
+	s.Require().Equal(storage.StatusVERIFIED, revoked[0].Status)
 
 	err = tx.SaveCelestials(ctx, slices.Values(celIds))
 	s.Require().NoError(err)
@@ -173,11 +369,11 @@ func (s *CelestialsTestSuite) TestCelestialsTransaction() {
 	s.Require().NoError(tx.Flush(ctx))
 	s.Require().NoError(tx.Close(ctx))
 
-	state1, err := s.celestialState.ByName(ctx, "indexer")
+	state1, err := s.celestialState.ByName(ctx, "indexer", testNetwork)
 	s.Require().NoError(err)
 	s.Require().EqualValues(celIds[1].ChangeId, state1.ChangeId)
 
-	item, err := s.celestials.ById(ctx, "name 3")
+	item, err := s.celestials.ById(ctx, testNetwork, "name 3")
 	s.Require().NoError(err)
 	s.Require().EqualValues("image_url", item.ImageUrl)
 	s.Require().EqualValues("name 3", item.Id)
@@ -185,7 +381,7 @@ func (s *CelestialsTestSuite) TestCelestialsTransaction() {
 	s.Require().EqualValues(1, item.AddressId)
 	s.Require().EqualValues(storage.StatusPRIMARY, item.Status)
 
-	item2, err := s.celestials.ById(ctx, "name 4")
+	item2, err := s.celestials.ById(ctx, testNetwork, "name 4")
 	s.Require().NoError(err)
 	s.Require().EqualValues("image_url2", item2.ImageUrl)
 	s.Require().EqualValues("name 4", item2.Id)
@@ -193,7 +389,7 @@ func (s *CelestialsTestSuite) TestCelestialsTransaction() {
 	s.Require().EqualValues(3, item2.AddressId)
 	s.Require().EqualValues(storage.StatusPRIMARY, item.Status)
 
-	item3, err := s.celestials.ById(ctx, "name 1")
+	item3, err := s.celestials.ById(ctx, testNetwork, "name 1")
 	s.Require().NoError(err)
 	s.Require().EqualValues("", item3.ImageUrl)
 	s.Require().EqualValues("name 1", item3.Id)
@@ -202,17 +398,308 @@ func (s *CelestialsTestSuite) TestCelestialsTransaction() {
 	s.Require().EqualValues(storage.StatusVERIFIED, item3.Status)
 }
 
+func (s *CelestialsTestSuite) TestSaveCelestialsTimestamps() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	const network = "timestamps"
+
+	save := func(primaries map[uint64]string, celIds ...storage.Celestial) {
+		tx, err := BeginCelestialTransaction(ctx, s.storage.Transactable)
+		s.Require().NoError(err)
+		_, err = tx.UpdateStatusForAddress(ctx, network, primaries)
+		s.Require().NoError(err)
+		s.Require().NoError(tx.SaveCelestials(ctx, slices.Values(celIds)))
+		s.Require().NoError(tx.Flush(ctx))
+		s.Require().NoError(tx.Close(ctx))
+	}
+
+	save(map[uint64]string{1: "a"}, storage.Celestial{Network: network, Id: "a", AddressId: 1, ChangeId: 1, Status: storage.StatusPRIMARY})
+	created, err := s.celestials.ById(ctx, network, "a")
+	s.Require().NoError(err)
+	s.Require().False(created.CreatedAt.IsZero())
+	s.Require().Equal(created.CreatedAt, created.UpdatedAt)
+	s.Require().Equal(created.CreatedAt, created.StatusChangedAt)
+
+	save(map[uint64]string{1: "a"}, storage.Celestial{Network: network, Id: "a", AddressId: 1, ChangeId: 2, ImageUrl: "image", Status: storage.StatusPRIMARY})
+	updated, err := s.celestials.ById(ctx, network, "a")
+	s.Require().NoError(err)
+	s.Require().Equal(created.CreatedAt, updated.CreatedAt)
+	s.Require().Equal(created.StatusChangedAt, updated.StatusChangedAt)
+	s.Require().True(updated.UpdatedAt.After(created.UpdatedAt))
+
+	save(map[uint64]string{1: "b"}, storage.Celestial{Network: network, Id: "b", AddressId: 1, ChangeId: 3, Status: storage.StatusPRIMARY})
+	demoted, err := s.celestials.ById(ctx, network, "a")
+	s.Require().NoError(err)
+	s.Require().Equal(storage.StatusVERIFIED, demoted.Status)
+	s.Require().Equal(created.CreatedAt, demoted.CreatedAt)
+	s.Require().True(demoted.StatusChangedAt.After(updated.StatusChangedAt))
+	s.Require().Equal(demoted.UpdatedAt, demoted.StatusChangedAt)
+}
+
+func (s *CelestialsTestSuite) TestUpdateStateStale() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	current, err := s.celestialState.ByName(ctx, "indexer", testNetwork)
+	s.Require().NoError(err)
+
+	tx, err := BeginCelestialTransaction(ctx, s.storage.Transactable)
+	s.Require().NoError(err)
+
+	stale := current
+	stale.ChangeId--
+	err = tx.UpdateState(ctx, &stale)
+	s.Require().ErrorIs(err, storage.ErrStaleState)
+	s.Require().NoError(tx.UpdateState(ctx, &current))
+	s.Require().NoError(tx.Flush(ctx))
+	s.Require().NoError(tx.Close(ctx))
+
+	state, err := s.celestialState.ByName(ctx, "indexer", testNetwork)
+	s.Require().NoError(err)
+	s.Require().EqualValues(current.ChangeId, state.ChangeId)
+}
+
+func (s *CelestialsTestSuite) TestSwapShadow() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	const network = "shadow"
+
+	now := time.Now().UTC()
+	tx, err := BeginCelestialTransaction(ctx, s.storage.Transactable)
+	s.Require().NoError(err)
+	s.Require().NoError(tx.SaveCelestials(ctx, slices.Values([]storage.Celestial{
+		{Network: network, Id: "live", AddressId: 1, ChangeId: 1, Status: storage.StatusPRIMARY},
+		{Network: network, Id: "shadow 1", AddressId: 1, ChangeId: 1, Status: storage.StatusVERIFIED},
+	})))
+	s.Require().NoError(tx.SaveHistory(ctx,
+		storage.CelestialHistory{IndexedAt: now, Network: network, ChangeId: 1, CelestialId: "live", AddressId: 1, Status: storage.StatusPRIMARY},
+	))
+	s.Require().NoError(tx.CreateShadow(ctx, network))
+	s.Require().NoError(tx.SaveShadowCelestials(ctx, slices.Values([]storage.Celestial{
+		{Network: network, Id: "shadow 1", AddressId: 1, ChangeId: 1, Status: storage.StatusVERIFIED},
+		{Network: network, Id: "shadow 2", AddressId: 1, ChangeId: 2, Status: storage.StatusPRIMARY},
+	})))
+	s.Require().NoError(tx.SaveShadowHistory(ctx,
+		storage.CelestialHistory{IndexedAt: now, Network: network, ChangeId: 1, CelestialId: "shadow 1", AddressId: 1, Status: storage.StatusVERIFIED},
+		storage.CelestialHistory{IndexedAt: now, Network: network, ChangeId: 2, CelestialId: "shadow 2", AddressId: 1, Status: storage.StatusPRIMARY},
+	))
+	_, err = tx.UpdateShadowStatusForAddress(ctx, network, map[uint64]string{1: "shadow 2"})
+	s.Require().NoError(err)
+	s.Require().NoError(tx.Flush(ctx))
+	s.Require().NoError(tx.Close(ctx))
+
+	_, err = s.celestials.ById(ctx, network, "shadow 2")
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+
+	tx, err = BeginCelestialTransaction(ctx, s.storage.Transactable)
+	s.Require().NoError(err)
+	before, after, err := tx.SwapShadow(ctx, network)
+	s.Require().NoError(err)
+	s.Require().NoError(tx.Flush(ctx))
+	s.Require().NoError(tx.Close(ctx))
+
+	s.Require().Len(before, 1)
+	s.Require().Equal("live", before[0].Id)
+	s.Require().Len(after, 1)
+	s.Require().Equal("shadow 2", after[0].Id)
+
+	_, err = s.celestials.ById(ctx, network, "live")
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+
+	items, _, err := s.celestials.ByAddressId(ctx, network, 1, storage.ListOptions{Limit: 10})
+	s.Require().NoError(err)
+	s.Require().Len(items, 2)
+	s.Require().Equal("shadow 2", items[0].Id)
+	s.Require().Equal(storage.StatusPRIMARY, items[0].Status)
+	s.Require().Equal("shadow 1", items[1].Id)
+	s.Require().Equal(storage.StatusVERIFIED, items[1].Status)
+
+	history, err := s.history.ByAddressId(ctx, network, 1, 0, 10)
+	s.Require().NoError(err)
+	s.Require().Len(history, 2)
+	s.Require().Equal("shadow 1", history[0].CelestialId)
+	s.Require().Equal("shadow 2", history[1].CelestialId)
+
+	_, err = s.celestials.ById(ctx, testNetwork, "name 1")
+	s.Require().NoError(err)
+}
+
+func (s *CelestialsTestSuite) TestRollbackTo() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	const network = "rollback"
+
+	state := storage.CelestialState{Name: "indexer", Network: network, ChangeId: 3}
+	s.Require().NoError(s.celestialState.Save(ctx, &state))
+
+	now := time.Now().UTC()
+	tx, err := BeginCelestialTransaction(ctx, s.storage.Transactable)
+	s.Require().NoError(err)
+	s.Require().NoError(tx.SaveHistory(ctx,
+		storage.CelestialHistory{IndexedAt: now, Network: network, ChangeId: 1, CelestialId: "a", AddressId: 1, Status: storage.StatusPRIMARY},
+		storage.CelestialHistory{IndexedAt: now, Network: network, ChangeId: 2, CelestialId: "b", AddressId: 1, Status: storage.StatusPRIMARY},
+		storage.CelestialHistory{IndexedAt: now, Network: network, ChangeId: 3, CelestialId: "a", AddressId: 2, Status: storage.StatusPRIMARY},
+	))
+	s.Require().NoError(tx.SaveCelestials(ctx, slices.Values([]storage.Celestial{
+		{Network: network, Id: "a", AddressId: 2, ChangeId: 3, Status: storage.StatusPRIMARY},
+		{Network: network, Id: "b", AddressId: 1, ChangeId: 2, Status: storage.StatusPRIMARY},
+	})))
+	s.Require().NoError(tx.SaveFailedChanges(ctx, storage.CelestialFailedChange{Network: network, ChangeId: 3, CelestialId: "a", Address: "address", Status: "PRIMARY", NextRetryAt: now}))
+	_, _, err = tx.RollbackTo(ctx, &state, 4)
+	s.Require().Error(err)
+	before, after, err := tx.RollbackTo(ctx, &state, 2)
+	s.Require().NoError(err)
+	s.Require().Len(before, 1)
+	s.Require().Equal("a", before[0].Id)
+	s.Require().EqualValues(2, before[0].AddressId)
+	s.Require().Len(after, 1)
+	s.Require().Equal("a", after[0].Id)
+	s.Require().EqualValues(1, after[0].AddressId)
+	s.Require().Equal(storage.StatusVERIFIED, after[0].Status)
+	s.Require().NoError(tx.Flush(ctx))
+	s.Require().NoError(tx.Close(ctx))
+	s.Require().EqualValues(2, state.ChangeId)
+
+	a, err := s.celestials.ById(ctx, network, "a")
+	s.Require().NoError(err)
+	s.Require().EqualValues(1, a.AddressId)
+	s.Require().EqualValues(1, a.ChangeId)
+	s.Require().Equal(storage.StatusVERIFIED, a.Status)
+
+	b, err := s.celestials.ById(ctx, network, "b")
+	s.Require().NoError(err)
+	s.Require().Equal(storage.StatusPRIMARY, b.Status)
+
+	tx, err = BeginCelestialTransaction(ctx, s.storage.Transactable)
+	s.Require().NoError(err)
+	failed, err := tx.FailedChanges(ctx, network, 10)
+	s.Require().NoError(err)
+	s.Require().Empty(failed)
+	before, after, err = tx.RollbackTo(ctx, &state, 1)
+	s.Require().NoError(err)
+	s.Require().Len(before, 2)
+	s.Require().Len(after, 1)
+	s.Require().Equal("a", after[0].Id)
+	s.Require().Equal(storage.StatusPRIMARY, after[0].Status)
+	s.Require().NoError(tx.Flush(ctx))
+	s.Require().NoError(tx.Close(ctx))
+
+	a, err = s.celestials.ById(ctx, network, "a")
+	s.Require().NoError(err)
+	s.Require().Equal(storage.StatusPRIMARY, a.Status)
+
+	_, err = s.celestials.ById(ctx, network, "b")
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+
+	stored, err := s.celestialState.ByName(ctx, "indexer", network)
+	s.Require().NoError(err)
+	s.Require().EqualValues(1, stored.ChangeId)
+
+	history, err := s.history.ById(ctx, network, "a", 0, 10)
+	s.Require().NoError(err)
+	s.Require().Len(history, 1)
+
+	tx, err = BeginCelestialTransaction(ctx, s.storage.Transactable)
+	s.Require().NoError(err)
+	_, _, err = tx.RollbackTo(ctx, &stored, 0)
+	s.Require().Error(err)
+	s.Require().NoError(tx.Rollback(ctx))
+	s.Require().NoError(tx.Close(ctx))
+
+	a, err = s.celestials.ById(ctx, network, "a")
+	s.Require().NoError(err)
+	s.Require().Equal(storage.StatusPRIMARY, a.Status)
+}
+
+func (s *CelestialsTestSuite) TestPrimaryUniqueIndex() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	const network = "repair"
+
+	err := s.storage.Connection().DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DROP INDEX celestial_network_address_id_primary_idx"); err != nil {
+			return err
+		}
+		if _, err := tx.NewInsert().Model(&[]storage.Celestial{
+			{Network: network, Id: "old", AddressId: 1, ChangeId: 1, Status: storage.StatusPRIMARY},
+			{Network: network, Id: "new", AddressId: 1, ChangeId: 2, Status: storage.StatusPRIMARY},
+			{Network: network, Id: "other", AddressId: 2, ChangeId: 1, Status: storage.StatusPRIMARY},
+		}).Exec(ctx); err != nil {
+			return err
+		}
+		return CreateIndex(ctx, tx)
+	})
+	s.Require().NoError(err)
+
+	old, err := s.celestials.ById(ctx, network, "old")
+	s.Require().NoError(err)
+	s.Require().Equal(storage.StatusVERIFIED, old.Status)
+
+	primary, err := s.celestials.Primary(ctx, network, 1)
+	s.Require().NoError(err)
+	s.Require().Equal("new", primary.Id)
+
+	other, err := s.celestials.ById(ctx, network, "other")
+	s.Require().NoError(err)
+	s.Require().Equal(storage.StatusPRIMARY, other.Status)
+
+	tx, err := BeginCelestialTransaction(ctx, s.storage.Transactable)
+	s.Require().NoError(err)
+	err = tx.SaveCelestials(ctx, slices.Values([]storage.Celestial{
+		{Network: network, Id: "duplicate", AddressId: 1, ChangeId: 3, Status: storage.StatusPRIMARY},
+	}))
+	s.Require().Error(err)
+	s.Require().NoError(tx.Rollback(ctx))
+	s.Require().NoError(tx.Close(ctx))
+}
+
+func (s *CelestialsTestSuite) TestAdvisoryLock() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	leader := NewAdvisoryLock(s.storage.Connection(), "indexer")
+	follower := NewAdvisoryLock(s.storage.Connection(), "indexer")
+	other := NewAdvisoryLock(s.storage.Connection(), "other")
+
+	locked, err := leader.TryLock(ctx)
+	s.Require().NoError(err)
+	s.Require().True(locked)
+	s.Require().NoError(leader.Check(ctx))
+
+	locked, err = follower.TryLock(ctx)
+	s.Require().NoError(err)
+	s.Require().False(locked)
+	s.Require().Error(follower.Check(ctx))
+
+	locked, err = other.TryLock(ctx)
+	s.Require().NoError(err)
+	s.Require().True(locked)
+	s.Require().NoError(other.Unlock(ctx))
+
+	s.Require().NoError(leader.Unlock(ctx))
+
+	locked, err = follower.TryLock(ctx)
+	s.Require().NoError(err)
+	s.Require().True(locked)
+	s.Require().NoError(follower.Unlock(ctx))
+}
+
 func (s *CelestialsTestSuite) TestCelestialsStateSave() {
 	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer ctxCancel()
 
 	err := s.celestialState.Save(ctx, &storage.CelestialState{
 		Name:     "new",
+		Network:  testNetwork,
 		ChangeId: 10,
 	})
 	s.Require().NoError(err)
 
-	state, err := s.celestialState.ByName(ctx, "new")
+	state, err := s.celestialState.ByName(ctx, "new", testNetwork)
 	s.Require().NoError(err)
 	s.Require().EqualValues(10, state.ChangeId)
 	s.Require().EqualValues("new", state.Name)
@@ -222,8 +709,290 @@ func (s *CelestialsTestSuite) TestCelestialsStateByName() {
 	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer ctxCancel()
 
-	state, err := s.celestialState.ByName(ctx, "indexer")
+	state, err := s.celestialState.ByName(ctx, "indexer", testNetwork)
 	s.Require().NoError(err)
 	s.Require().EqualValues(3, state.ChangeId)
 	s.Require().EqualValues("indexer", state.Name)
 }
+
+func (s *CelestialsTestSuite) TestFailedChanges() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	tx, err := BeginCelestialTransaction(ctx, s.storage.Transactable)
+	s.Require().NoError(err)
+
+	err = tx.SaveFailedChanges(ctx,
+		storage.CelestialFailedChange{
+			Network:     testNetwork,
+			ChangeId:    10,
+			CelestialId: "failed 1",
+			Address:     "address",
+			Status:      "PRIMARY",
+			Error:       "error",
+			Attempts:    1,
+			NextRetryAt: time.Now().Add(-time.Minute),
+		},
+		storage.CelestialFailedChange{
+			Network:     testNetwork,
+			ChangeId:    11,
+			CelestialId: "failed 2",
+			Address:     "address",
+			Status:      "VERIFIED",
+			Error:       "error",
+			Attempts:    1,
+			NextRetryAt: time.Now().Add(time.Hour),
+		},
+	)
+	s.Require().NoError(err)
+	s.Require().NoError(tx.Flush(ctx))
+	s.Require().NoError(tx.Close(ctx))
+
+	items, err := s.failedChanges.List(ctx, testNetwork, 10, 0)
+	s.Require().NoError(err)
+	s.Require().Len(items, 2)
+	s.Require().EqualValues(10, items[0].ChangeId)
+	s.Require().EqualValues("failed 1", items[0].CelestialId)
+	s.Require().EqualValues(11, items[1].ChangeId)
+
+	tx, err = BeginCelestialTransaction(ctx, s.storage.Transactable)
+	s.Require().NoError(err)
+
+	ready, err := tx.FailedChanges(ctx, testNetwork, 10)
+	s.Require().NoError(err)
+	s.Require().Len(ready, 1)
+	s.Require().EqualValues(10, ready[0].ChangeId)
+
+	ready[0].Attempts++
+	ready[0].Error = "new error"
+	s.Require().NoError(tx.SaveFailedChanges(ctx, ready[0]))
+	s.Require().NoError(tx.DeleteFailedChanges(ctx, testNetwork, 11))
+	s.Require().NoError(tx.Flush(ctx))
+	s.Require().NoError(tx.Close(ctx))
+
+	items, err = s.failedChanges.List(ctx, testNetwork, 10, 0)
+	s.Require().NoError(err)
+	s.Require().Len(items, 1)
+	s.Require().EqualValues(2, items[0].Attempts)
+	s.Require().EqualValues("new error", items[0].Error)
+
+	tx, err = BeginCelestialTransaction(ctx, s.storage.Transactable)
+	s.Require().NoError(err)
+	replayed := items[0]
+	replayed.Attempts = 0
+	replayed.NextRetryAt = time.Now()
+	s.Require().NoError(tx.SaveNewFailedChanges(ctx, replayed))
+	s.Require().NoError(tx.Flush(ctx))
+	s.Require().NoError(tx.Close(ctx))
+
+	items, err = s.failedChanges.List(ctx, testNetwork, 10, 0)
+	s.Require().NoError(err)
+	s.Require().Len(items, 1)
+	s.Require().EqualValues(2, items[0].Attempts)
+
+	tx, err = BeginCelestialTransaction(ctx, s.storage.Transactable)
+	s.Require().NoError(err)
+	s.Require().NoError(tx.DeleteFailedChanges(ctx, testNetwork, 10))
+	s.Require().NoError(tx.Flush(ctx))
+	s.Require().NoError(tx.Close(ctx))
+
+	_, err = s.failedChanges.List(ctx, testNetwork, 101, 0)
+	s.Require().ErrorIs(err, storage.ErrInvalidLimit)
+	_, err = s.failedChanges.List(ctx, testNetwork, 0, 0)
+	s.Require().ErrorIs(err, storage.ErrInvalidLimit)
+}
+
+func (s *CelestialsTestSuite) TestHistoryById() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	items, err := s.history.ById(ctx, testNetwork, "name 3", 0, 10)
+	s.Require().NoError(err)
+	s.Require().Len(items, 1)
+
+	item := items[0]
+	s.Require().EqualValues(3, item.ChangeId)
+	s.Require().EqualValues("name 3", item.CelestialId)
+	s.Require().EqualValues(2, item.AddressId)
+	s.Require().EqualValues(storage.StatusPRIMARY, item.Status)
+
+	items, err = s.history.ById(ctx, testNetwork, "name 3", 3, 10)
+	s.Require().NoError(err)
+	s.Require().Len(items, 0)
+}
+
+func (s *CelestialsTestSuite) TestHistoryByAddressId() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	items, err := s.history.ByAddressId(ctx, testNetwork, 1, 0, 10)
+	s.Require().NoError(err)
+	s.Require().Len(items, 2)
+	s.Require().EqualValues(1, items[0].ChangeId)
+	s.Require().EqualValues("name 1", items[0].CelestialId)
+	s.Require().EqualValues(2, items[1].ChangeId)
+	s.Require().EqualValues("name 2", items[1].CelestialId)
+
+	items, err = s.history.ByAddressId(ctx, testNetwork, 1, 1, 1)
+	s.Require().NoError(err)
+	s.Require().Len(items, 1)
+	s.Require().EqualValues(2, items[0].ChangeId)
+}
+
+func (s *CelestialsTestSuite) TestSaveHistory() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	tx, err := BeginCelestialTransaction(ctx, s.storage.Transactable)
+	s.Require().NoError(err)
+
+	err = tx.SaveHistory(ctx,
+		storage.CelestialHistory{
+			IndexedAt:   time.Now().UTC(),
+			Network:     testNetwork,
+			ChangeId:    100,
+			CelestialId: "history",
+			AddressId:   100,
+			ImageUrl:    "image_url",
+			Status:      storage.StatusVERIFIED,
+		},
+		storage.CelestialHistory{
+			IndexedAt:   time.Now().UTC(),
+			Network:     testNetwork,
+			ChangeId:    101,
+			CelestialId: "history",
+			AddressId:   101,
+			ImageUrl:    "image_url",
+			Status:      storage.StatusPRIMARY,
+		},
+	)
+	s.Require().NoError(err)
+	s.Require().NoError(tx.Flush(ctx))
+	s.Require().NoError(tx.Close(ctx))
+
+	items, err := s.history.ById(ctx, testNetwork, "history", 0, 10)
+	s.Require().NoError(err)
+	s.Require().Len(items, 2)
+	s.Require().EqualValues(100, items[0].ChangeId)
+	s.Require().EqualValues(100, items[0].AddressId)
+	s.Require().EqualValues(101, items[1].ChangeId)
+	s.Require().EqualValues(101, items[1].AddressId)
+	s.Require().EqualValues(storage.StatusPRIMARY, items[1].Status)
+}
+
+func (s *CelestialsTestSuite) TestHistoryByIdAt() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	for _, tt := range []struct {
+		changeId  int64
+		addressId uint64
+		status    storage.Status
+	}{
+		{10, 20, storage.StatusPRIMARY},
+		{11, 20, storage.StatusPRIMARY},
+		{12, 20, storage.StatusVERIFIED},
+		{13, 21, storage.StatusPRIMARY},
+		{14, 21, storage.StatusVERIFIED},
+	} {
+		item, err := s.history.ByIdAt(ctx, testNetwork, "travel 1", tt.changeId)
+		s.Require().NoError(err, tt.changeId)
+		s.Require().EqualValues("travel 1", item.Id, tt.changeId)
+		s.Require().EqualValues(tt.addressId, item.AddressId, tt.changeId)
+		s.Require().EqualValues(tt.status, item.Status, tt.changeId)
+	}
+
+	_, err := s.history.ByIdAt(ctx, testNetwork, "travel 1", 9)
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+}
+
+func (s *CelestialsTestSuite) TestHistoryByAddressIdAt() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	items, err := s.history.ByAddressIdAt(ctx, testNetwork, 20, 12, 10, 0)
+	s.Require().NoError(err)
+	s.Require().Len(items, 2)
+	s.Require().EqualValues("travel 2", items[0].Id)
+	s.Require().EqualValues(12, items[0].ChangeId)
+	s.Require().EqualValues(storage.StatusPRIMARY, items[0].Status)
+	s.Require().EqualValues("travel 1", items[1].Id)
+	s.Require().EqualValues(10, items[1].ChangeId)
+	s.Require().EqualValues(storage.StatusVERIFIED, items[1].Status)
+
+	items, err = s.history.ByAddressIdAt(ctx, testNetwork, 20, 13, 10, 0)
+	s.Require().NoError(err)
+	s.Require().Len(items, 1)
+	s.Require().EqualValues("travel 2", items[0].Id)
+
+	items, err = s.history.ByAddressIdAt(ctx, testNetwork, 21, 12, 10, 0)
+	s.Require().NoError(err)
+	s.Require().Len(items, 0)
+}
+
+func (s *CelestialsTestSuite) TestHistoryPrimaryAtChangeId() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	for _, tt := range []struct {
+		addressId uint64
+		changeId  int64
+		id        string
+	}{
+		{20, 10, "travel 1"},
+		{20, 11, "travel 1"},
+		{20, 12, "travel 2"},
+		{20, 14, "travel 2"},
+		{21, 13, "travel 1"},
+		{21, 14, "travel 3"},
+	} {
+		item, err := s.history.PrimaryAtChangeId(ctx, testNetwork, tt.addressId, tt.changeId)
+		s.Require().NoError(err, tt.changeId)
+		s.Require().EqualValues(tt.id, item.Id, tt.changeId)
+		s.Require().EqualValues(tt.addressId, item.AddressId, tt.changeId)
+		s.Require().EqualValues(storage.StatusPRIMARY, item.Status, tt.changeId)
+	}
+
+	_, err := s.history.PrimaryAtChangeId(ctx, testNetwork, 20, 9)
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+
+	_, err = s.history.PrimaryAtChangeId(ctx, testNetwork, 21, 12)
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+}
+
+func (s *CelestialsTestSuite) TestHistoryPrimaryAt() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	for _, tt := range []struct {
+		addressId uint64
+		at        string
+		id        string
+	}{
+		{20, "2024-02-01T12:00:00Z", "travel 1"},
+		{20, "2024-02-02T12:00:00Z", "travel 1"},
+		{20, "2024-02-03T12:00:00Z", "travel 2"},
+		{20, "2024-02-10T00:00:00Z", "travel 2"},
+		{21, "2024-02-04T12:00:00Z", "travel 1"},
+		{21, "2024-02-05T12:00:00Z", "travel 3"},
+	} {
+		at, err := time.Parse(time.RFC3339, tt.at)
+		s.Require().NoError(err)
+
+		item, err := s.history.PrimaryAt(ctx, testNetwork, tt.addressId, at)
+		s.Require().NoError(err, tt.at)
+		s.Require().EqualValues(tt.id, item.Id, tt.at)
+		s.Require().EqualValues(tt.addressId, item.AddressId, tt.at)
+		s.Require().EqualValues(storage.StatusPRIMARY, item.Status, tt.at)
+	}
+
+	at, err := time.Parse(time.RFC3339, "2024-01-15T00:00:00Z")
+	s.Require().NoError(err)
+	_, err = s.history.PrimaryAt(ctx, testNetwork, 20, at)
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+
+	at, err = time.Parse(time.RFC3339, "2024-02-04T12:00:00Z")
+	s.Require().NoError(err)
+	_, err = s.history.PrimaryAt(ctx, testNetwork, 21, at.Add(-time.Hour*24))
+	s.Require().ErrorIs(err, sql.ErrNoRows)
+}
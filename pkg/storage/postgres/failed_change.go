@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/dipdup-io/go-lib/database"
+)
+
+type CelestialFailedChanges struct {
+	*database.Bun
+}
+
+func NewCelestialFailedChanges(db *database.Bun) *CelestialFailedChanges {
+	return &CelestialFailedChanges{
+		Bun: db,
+	}
+}
+
+// List - returns failed changes of network ordered by change id. It returns ErrInvalidLimit if limit is not in range [1, MaxLimit].
+func (fc *CelestialFailedChanges) List(ctx context.Context, network string, limit, offset int) (result []storage.CelestialFailedChange, err error) {
+	if err := storage.ValidateLimit(limit); err != nil {
+		return nil, err
+	}
+
+	err = fc.DB().NewSelect().
+		Model(&result).
+		Where("network = ?", network).
+		Offset(offset).
+		OrderExpr("change_id asc").
+		Limit(limit).
+		Scan(ctx)
+	return
+}
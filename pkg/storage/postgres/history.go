@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/dipdup-io/go-lib/database"
+)
+
+type CelestialHistory struct {
+	*database.Bun
+}
+
+func NewCelestialHistory(db *database.Bun) *CelestialHistory {
+	return &CelestialHistory{
+		Bun: db,
+	}
+}
+
+func (h *CelestialHistory) ById(ctx context.Context, network, id string, fromChangeId int64, limit int) (result []storage.CelestialHistory, err error) {
+	query := h.DB().NewSelect().
+		Model(&result).
+		Where("network = ?", network).
+		Where("celestial_id = ?", id).
+		Where("change_id > ?", fromChangeId).
+		OrderExpr("change_id asc")
+
+	if err := storage.ValidateLimit(limit); err != nil {
+		return nil, err
+	}
+
+	err = query.Limit(limit).Scan(ctx)
+	return
+}
+
+func (h *CelestialHistory) ByAddressId(ctx context.Context, network string, addressId uint64, fromChangeId int64, limit int) (result []storage.CelestialHistory, err error) {
+	query := h.DB().NewSelect().
+		Model(&result).
+		Where("network = ?", network).
+		Where("address_id = ?", addressId).
+		Where("change_id > ?", fromChangeId).
+		OrderExpr("change_id asc")
+
+	if err := storage.ValidateLimit(limit); err != nil {
+		return nil, err
+	}
+
+	err = query.Limit(limit).Scan(ctx)
+	return
+}
+
+const (
+	// primary status is revoked when other celestial id becomes primary for the same address later
+	byIdAtQuery = `SELECT h.celestial_id AS id, h.network, h.address_id, h.image_url, h.change_id,
+		CASE WHEN h.status = 'PRIMARY' AND EXISTS (
+			SELECT 1 FROM celestial_history AS p
+			WHERE p.network = h.network AND p.address_id = h.address_id AND p.status = 'PRIMARY' AND p.celestial_id != h.celestial_id
+				AND p.change_id > h.change_id AND p.change_id <= ?0
+		) THEN 'VERIFIED'::celestials_status ELSE h.status END AS status
+	FROM celestial_history AS h
+	WHERE h.network = ?2 AND h.celestial_id = ?1 AND h.change_id <= ?0
+	ORDER BY h.change_id DESC, h.indexed_at DESC
+	LIMIT 1`
+
+	byAddressIdAtQuery = `WITH latest AS (
+		SELECT DISTINCT ON (celestial_id) celestial_id, network, address_id, image_url, change_id, status
+		FROM celestial_history
+		WHERE network = ?4 AND change_id <= ?0 AND celestial_id IN (
+			SELECT celestial_id FROM celestial_history WHERE network = ?4 AND address_id = ?1 AND change_id <= ?0
+		)
+		ORDER BY celestial_id, change_id DESC, indexed_at DESC
+	)
+	SELECT l.celestial_id AS id, l.network, l.address_id, l.image_url, l.change_id,
+		CASE WHEN l.status = 'PRIMARY' AND EXISTS (
+			SELECT 1 FROM celestial_history AS p
+			WHERE p.network = l.network AND p.address_id = l.address_id AND p.status = 'PRIMARY' AND p.celestial_id != l.celestial_id
+				AND p.change_id > l.change_id AND p.change_id <= ?0
+		) THEN 'VERIFIED'::celestials_status ELSE l.status END AS status
+	FROM latest AS l
+	WHERE l.address_id = ?1
+	ORDER BY l.change_id DESC
+	LIMIT ?2 OFFSET ?3`
+
+	// the latest primary celestial id of address which was not revoked by other primary celestial id later
+	primaryAtChangeIdQuery = `WITH latest AS (
+		SELECT DISTINCT ON (celestial_id) celestial_id, network, address_id, image_url, change_id, status
+		FROM celestial_history
+		WHERE network = ?2 AND change_id <= ?0 AND celestial_id IN (
+			SELECT celestial_id FROM celestial_history WHERE network = ?2 AND address_id = ?1 AND status = 'PRIMARY' AND change_id <= ?0
+		)
+		ORDER BY celestial_id, change_id DESC, indexed_at DESC
+	)
+	SELECT l.celestial_id AS id, l.network, l.address_id, l.image_url, l.change_id, l.status
+	FROM latest AS l
+	WHERE l.address_id = ?1 AND l.status = 'PRIMARY' AND NOT EXISTS (
+		SELECT 1 FROM celestial_history AS p
+		WHERE p.network = l.network AND p.address_id = l.address_id AND p.status = 'PRIMARY' AND p.celestial_id != l.celestial_id
+			AND p.change_id > l.change_id AND p.change_id <= ?0
+	)
+	ORDER BY l.change_id DESC
+	LIMIT 1`
+
+	// the last primary change of address is actual if celestial id was not changed after it
+	primaryAtQuery = `SELECT h.celestial_id AS id, h.network, h.address_id, h.image_url, h.change_id, h.status
+	FROM (
+		SELECT * FROM celestial_history
+		WHERE network = ?2 AND address_id = ?1 AND status = 'PRIMARY' AND indexed_at <= ?0
+		ORDER BY change_id DESC, indexed_at DESC
+		LIMIT 1
+	) AS h
+	WHERE NOT EXISTS (
+		SELECT 1 FROM celestial_history AS n
+		WHERE n.network = h.network AND n.celestial_id = h.celestial_id AND n.change_id > h.change_id AND n.indexed_at <= ?0
+	)`
+)
+
+func (h *CelestialHistory) ByIdAt(ctx context.Context, network, id string, changeId int64) (result storage.Celestial, err error) {
+	err = h.DB().NewRaw(byIdAtQuery, changeId, id, network).Scan(ctx, &result)
+	return
+}
+
+func (h *CelestialHistory) ByAddressIdAt(ctx context.Context, network string, addressId uint64, changeId int64, limit, offset int) (result []storage.Celestial, err error) {
+	if err := storage.ValidateLimit(limit); err != nil {
+		return nil, err
+	}
+	err = h.DB().NewRaw(byAddressIdAtQuery, changeId, addressId, limit, offset, network).Scan(ctx, &result)
+	return
+}
+
+func (h *CelestialHistory) PrimaryAtChangeId(ctx context.Context, network string, addressId uint64, changeId int64) (result storage.Celestial, err error) {
+	err = h.DB().NewRaw(primaryAtChangeIdQuery, changeId, addressId, network).Scan(ctx, &result)
+	return
+}
+
+// PrimaryAt - returns primary celestial id of address at the passed indexing time
+func (h *CelestialHistory) PrimaryAt(ctx context.Context, network string, addressId uint64, at time.Time) (result storage.Celestial, err error) {
+	err = h.DB().NewRaw(primaryAtQuery, at.UTC(), addressId, network).Scan(ctx, &result)
+	return
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/dipdup-io/go-lib/database"
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+)
+
+const (
+	createHypertableQuery = `SELECT create_hypertable(?, ?, chunk_time_interval => INTERVAL '1 month', if_not_exists => TRUE);`
+)
+
+// CreateHypertables - converts time-series tables to TimescaleDB hypertables. Tables should be created before the call.
+func CreateHypertables(ctx context.Context, conn *database.Bun) error {
+	log.Info().Msg("creating celestial hypertables...")
+	return conn.DB().RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.ExecContext(
+			ctx,
+			createHypertableQuery,
+			storage.CelestialHistory{}.TableName(),
+			"indexed_at",
+		); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
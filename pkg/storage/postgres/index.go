@@ -4,16 +4,21 @@ import (
 	"context"
 
 	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 	"github.com/uptrace/bun"
 )
 
-// CreateIndex - creates all needed indices in postgres database
+// CreateIndex - creates all needed indices in postgres database. Violations of one primary celestial id per address
+// are repaired before creating the unique index.
+// Tables created by previous versions must be migrated by MigrateLifecycleTimestamps before because repairing
+// updates timestamp columns.
 func CreateIndex(ctx context.Context, tx bun.Tx) error {
 	if _, err := tx.NewCreateIndex().
 		IfNotExists().
 		Model((*storage.Celestial)(nil)).
-		Index("celestial_address_id_idx").
-		Column("address_id").
+		Index("celestial_network_address_id_change_id_idx").
+		Column("network", "address_id", "change_id", "id").
 		Exec(ctx); err != nil {
 		return err
 	}
@@ -25,5 +30,69 @@ func CreateIndex(ctx context.Context, tx bun.Tx) error {
 		Exec(ctx); err != nil {
 		return err
 	}
+	if _, err := tx.ExecContext(ctx, "CREATE EXTENSION IF NOT EXISTS pg_trgm"); err != nil {
+		return errors.Wrap(err, "create pg_trgm extension")
+	}
+	if _, err := tx.NewCreateIndex().
+		IfNotExists().
+		Model((*storage.Celestial)(nil)).
+		Index("celestial_id_trgm_idx").
+		Using("GIN").
+		ColumnExpr("id gin_trgm_ops").
+		Exec(ctx); err != nil {
+		return err
+	}
+	repaired, err := RepairPrimaryStatuses(ctx, tx)
+	if err != nil {
+		return errors.Wrap(err, "repair primary statuses")
+	}
+	if repaired > 0 {
+		log.Warn().Int64("count", repaired).Msg("revoked duplicated primary statuses")
+	}
+	if _, err := tx.NewCreateIndex().
+		IfNotExists().
+		Model((*storage.Celestial)(nil)).
+		Index("celestial_network_address_id_primary_idx").
+		Unique().
+		Column("network", "address_id").
+		Where("status = 'PRIMARY'").
+		Exec(ctx); err != nil {
+		return err
+	}
+	if _, err := tx.NewCreateIndex().
+		IfNotExists().
+		Model((*storage.CelestialHistory)(nil)).
+		Index("celestial_history_network_celestial_id_idx").
+		Column("network", "celestial_id", "change_id").
+		Exec(ctx); err != nil {
+		return err
+	}
+	if _, err := tx.NewCreateIndex().
+		IfNotExists().
+		Model((*storage.CelestialHistory)(nil)).
+		Index("celestial_history_network_address_id_idx").
+		Column("network", "address_id", "change_id").
+		Exec(ctx); err != nil {
+		return err
+	}
 	return nil
 }
+
+// the latest primary celestial id of address is kept, ties are broken by celestial id
+const repairPrimaryStatusesQuery = `UPDATE celestial AS c SET status = 'VERIFIED', updated_at = now(), status_changed_at = now()
+	WHERE c.status = 'PRIMARY' AND EXISTS (
+		SELECT 1 FROM celestial AS p
+		WHERE p.network = c.network AND p.address_id = c.address_id AND p.status = 'PRIMARY'
+			AND (p.change_id > c.change_id OR (p.change_id = c.change_id AND p.id > c.id))
+	)`
+
+// RepairPrimaryStatuses - revokes primary status of celestial ids if address has several primary ones.
+// The one with the greatest change id stays primary. It returns count of revoked statuses.
+// It requires timestamp columns added by MigrateLifecycleTimestamps.
+func RepairPrimaryStatuses(ctx context.Context, db bun.IDB) (int64, error) {
+	result, err := db.ExecContext(ctx, repairPrimaryStatusesQuery)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
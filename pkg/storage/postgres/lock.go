@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"github.com/dipdup-io/go-lib/database"
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+)
+
+// AdvisoryLock - session-level postgres advisory lock. The lock is held by a dedicated connection,
+// so it is released automatically by the server when the session of the holder dies.
+type AdvisoryLock struct {
+	db  *database.Bun
+	key int64
+
+	mx   sync.Mutex
+	conn *bun.Conn
+}
+
+// NewAdvisoryLock - creates advisory lock which key is derived from the passed name
+func NewAdvisoryLock(db *database.Bun, name string) *AdvisoryLock {
+	return &AdvisoryLock{
+		db:  db,
+		key: advisoryLockKey(name),
+	}
+}
+
+func advisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("celestials:" + name))
+	return int64(h.Sum64()) //nolint:gosec
+}
+
+func (l *AdvisoryLock) TryLock(ctx context.Context) (bool, error) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if l.conn != nil {
+		return true, nil
+	}
+
+	conn, err := l.db.DB().Conn(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "receive connection")
+	}
+
+	var locked bool
+	if err := conn.NewRaw("SELECT pg_try_advisory_lock(?)", l.key).Scan(ctx, &locked); err != nil {
+		_ = conn.Close()
+		return false, errors.Wrap(err, "try advisory lock")
+	}
+	if !locked {
+		return false, conn.Close()
+	}
+
+	l.conn = &conn
+	return true, nil
+}
+
+func (l *AdvisoryLock) Check(ctx context.Context) error {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if l.conn == nil {
+		return errors.New("lock is not acquired")
+	}
+	if err := l.conn.PingContext(ctx); err != nil {
+		_ = l.conn.Close()
+		l.conn = nil
+		return errors.Wrap(err, "lock session is lost")
+	}
+	return nil
+}
+
+func (l *AdvisoryLock) Unlock(ctx context.Context) error {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+	defer func() {
+		_ = l.conn.Close()
+		l.conn = nil
+	}()
+
+	_, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock(?)", l.key)
+	return err
+}
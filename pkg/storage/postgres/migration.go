@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/dipdup-io/go-lib/database"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+)
+
+const (
+	hasNetworkColumnQuery = `SELECT EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ? AND column_name = 'network'
+	)`
+	hasTableQuery = `SELECT EXISTS (
+		SELECT 1 FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_name = ?
+	)`
+
+	addTimestampsQuery = `ALTER TABLE ?
+		ADD COLUMN IF NOT EXISTS created_at timestamptz NOT NULL DEFAULT now(),
+		ADD COLUMN IF NOT EXISTS updated_at timestamptz NOT NULL DEFAULT now(),
+		ADD COLUMN IF NOT EXISTS status_changed_at timestamptz NOT NULL DEFAULT now()`
+
+	// creation time is the first indexing time found in history, update time is the indexing time of the last change
+	backfillTimestampsQuery = `UPDATE ? AS c SET created_at = LEAST(c.created_at, h.first_indexed_at), updated_at = h.last_indexed_at
+		FROM (
+			SELECT network, celestial_id, min(indexed_at) AS first_indexed_at, max(indexed_at) AS last_indexed_at
+			FROM celestial_history
+			GROUP BY network, celestial_id
+		) AS h
+		WHERE h.network = c.network AND h.celestial_id = c.id AND c.created_at > h.first_indexed_at`
+)
+
+type networkMigration struct {
+	table      string
+	primaryKey string
+}
+
+// MigrateToMultiNetwork - adds network column to tables of single-network versions of the module: celestial
+// and celestial_state. Existing rows are assigned to the passed network and primary keys are extended by the network
+// column. Index celestial_address_id_idx on address_id only is replaced by network-aware indices of CreateIndex.
+// Other tables are created with network column from the start. Migration is idempotent: tables which already
+// have network column or do not exist are skipped.
+func MigrateToMultiNetwork(ctx context.Context, conn *database.Bun, network string) error {
+	if network == "" {
+		return errors.New("empty network")
+	}
+
+	migrations := []networkMigration{
+		{table: storage.Celestial{}.TableName(), primaryKey: "id, network"},
+		{table: storage.CelestialState{}.TableName(), primaryKey: "name, network"},
+	}
+
+	return conn.DB().RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DROP INDEX IF EXISTS celestial_address_id_idx"); err != nil {
+			return errors.Wrap(err, "drop celestial_address_id_idx")
+		}
+		for _, migration := range migrations {
+			if err := migrateTableToMultiNetwork(ctx, tx, migration, network); err != nil {
+				return errors.Wrap(err, migration.table)
+			}
+		}
+		return nil
+	})
+}
+
+func migrateTableToMultiNetwork(ctx context.Context, tx bun.Tx, migration networkMigration, network string) error {
+	var exists bool
+	if err := tx.NewRaw(hasTableQuery, migration.table).Scan(ctx, &exists); err != nil {
+		return errors.Wrap(err, "check table")
+	}
+	if !exists {
+		return nil
+	}
+	if err := tx.NewRaw(hasNetworkColumnQuery, migration.table).Scan(ctx, &exists); err != nil {
+		return errors.Wrap(err, "check network column")
+	}
+	if exists {
+		return nil
+	}
+
+	log.Info().Str("table", migration.table).Str("network", network).Msg("migrating table to multi-network schema...")
+
+	if _, err := tx.ExecContext(ctx,
+		`ALTER TABLE ? ADD COLUMN network varchar NOT NULL DEFAULT ?`,
+		bun.Ident(migration.table), network,
+	); err != nil {
+		return errors.Wrap(err, "add network column")
+	}
+	if _, err := tx.ExecContext(ctx,
+		`ALTER TABLE ? ALTER COLUMN network DROP DEFAULT`,
+		bun.Ident(migration.table),
+	); err != nil {
+		return errors.Wrap(err, "drop network default")
+	}
+	if _, err := tx.ExecContext(ctx,
+		`ALTER TABLE ? DROP CONSTRAINT IF EXISTS ?`,
+		bun.Ident(migration.table), bun.Ident(migration.table+"_pkey"),
+	); err != nil {
+		return errors.Wrap(err, "drop primary key")
+	}
+	if _, err := tx.ExecContext(ctx,
+		`ALTER TABLE ? ADD PRIMARY KEY (?)`,
+		bun.Ident(migration.table), bun.Safe(migration.primaryKey),
+	); err != nil {
+		return errors.Wrap(err, "add primary key")
+	}
+	return nil
+}
+
+// MigrateLifecycleTimestamps - adds created_at, updated_at and status_changed_at columns to celestial tables created by
+// previous versions of the module. Creation and update times are restored from history if it exists, status change time
+// is set to the migration time. Migration is idempotent. It should be called after MigrateToMultiNetwork
+// and before CreateIndex.
+func MigrateLifecycleTimestamps(ctx context.Context, conn *database.Bun) error {
+	tables := []string{storage.Celestial{}.TableName(), shadowCelestialTable}
+
+	return conn.DB().RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		var hasHistory bool
+		if err := tx.NewRaw(hasTableQuery, storage.CelestialHistory{}.TableName()).Scan(ctx, &hasHistory); err != nil {
+			return errors.Wrap(err, "check history table")
+		}
+
+		for _, table := range tables {
+			var exists bool
+			if err := tx.NewRaw(hasTableQuery, table).Scan(ctx, &exists); err != nil {
+				return errors.Wrapf(err, "check table %s", table)
+			}
+			if !exists {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, addTimestampsQuery, bun.Ident(table)); err != nil {
+				return errors.Wrapf(err, "add timestamps to %s", table)
+			}
+			if !hasHistory {
+				continue
+			}
+			result, err := tx.ExecContext(ctx, backfillTimestampsQuery, bun.Ident(table))
+			if err != nil {
+				return errors.Wrapf(err, "backfill timestamps of %s", table)
+			}
+			if count, err := result.RowsAffected(); err == nil && count > 0 {
+				log.Info().Str("table", table).Int64("count", count).Msg("timestamps are restored from history")
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/uptrace/bun"
+)
+
+// keyset - applies cursor, sort order and limit to query of celestial ids sorted by sort field and id.
+// One extra row is requested to detect if the next page exists.
+func keyset(query *bun.SelectQuery, opts storage.ListOptions) (*bun.SelectQuery, error) {
+	op, order := "<", "DESC"
+	if opts.Sort == storage.SortAsc {
+		op, order = ">", "ASC"
+	}
+	field := opts.OrderBy()
+
+	if opts.Cursor != "" {
+		key, id, err := opts.Cursor.Decode(field)
+		if err != nil {
+			return nil, err
+		}
+		var value any = key
+		if field != storage.SortByChangeId {
+			value = time.UnixMicro(key).UTC()
+		}
+		query = query.Where("(?, id) "+op+" (?, ?)", bun.Ident(field), value, id)
+	}
+	if len(opts.Status) > 0 {
+		query = query.Where("status IN (?)", bun.In(opts.Status))
+	}
+
+	return query.
+		OrderExpr("? "+order, bun.Ident(field)).
+		OrderExpr("id " + order).
+		Limit(opts.Limit + 1), nil
+}
+
+// nextPage - cuts the extra row requested by keyset and returns cursor of the next page or empty cursor for the last one
+func nextPage(items []storage.Celestial, opts storage.ListOptions) ([]storage.Celestial, storage.Cursor) {
+	if len(items) <= opts.Limit {
+		return items, ""
+	}
+	items = items[:opts.Limit]
+	return items, storage.NewCursor(opts.OrderBy(), items[len(items)-1])
+}
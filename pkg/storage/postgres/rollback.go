@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+const (
+	// restoredCelestialsQuery - the latest history row of every celestial id before rollback point. Primary status is revoked
+	// if other celestial id became primary for the same address later, the same as in history queries. Kept rows
+	// without history are taken into account too.
+	restoredCelestialsQuery = `WITH latest AS (
+		SELECT DISTINCT ON (celestial_id) celestial_id, network, address_id, image_url, change_id, status
+		FROM celestial_history
+		WHERE network = ?0 AND change_id <= ?1
+		ORDER BY celestial_id, change_id DESC, indexed_at DESC
+	), restored AS (
+		SELECT l.celestial_id AS id, l.network, l.address_id, l.image_url, l.change_id,
+			CASE WHEN l.status = 'PRIMARY' AND (EXISTS (
+				SELECT 1 FROM celestial_history AS p
+				WHERE p.network = l.network AND p.address_id = l.address_id AND p.status = 'PRIMARY' AND p.celestial_id != l.celestial_id
+					AND p.change_id > l.change_id AND p.change_id <= ?1
+			) OR EXISTS (
+				SELECT 1 FROM celestial AS k
+				WHERE k.network = l.network AND k.address_id = l.address_id AND k.status = 'PRIMARY' AND k.id != l.celestial_id
+					AND k.change_id > l.change_id
+			)) THEN 'VERIFIED'::celestials_status ELSE l.status END AS status
+		FROM latest AS l
+	)`
+
+	// primary statuses of addresses which get other restored primary celestial id are revoked first
+	// to keep one primary celestial id per address
+	revokePrimaryQuery = restoredCelestialsQuery + `
+	UPDATE celestial AS c SET status = 'VERIFIED', updated_at = now(), status_changed_at = now()
+	WHERE c.network = ?0 AND c.status = 'PRIMARY' AND EXISTS (
+		SELECT 1 FROM restored AS r WHERE r.status = 'PRIMARY' AND r.address_id = c.address_id AND r.id != c.id
+	)
+	RETURNING c.*`
+
+	// kept rows which statuses are changed by restoring
+	restoredStatusesQuery = restoredCelestialsQuery + `
+	SELECT c.* FROM celestial AS c
+	JOIN restored AS r ON r.network = c.network AND r.id = c.id
+	WHERE c.network = ?0 AND c.change_id = r.change_id AND c.status != r.status`
+
+	// restored rows are inserted with creation time of the first history row and statuses of kept rows are restored
+	restoreFromHistoryQuery = restoredCelestialsQuery + `
+	INSERT INTO celestial (id, network, address_id, image_url, change_id, status, created_at)
+	SELECT r.id, r.network, r.address_id, r.image_url, r.change_id, r.status, COALESCE((
+		SELECT min(h.indexed_at) FROM celestial_history AS h WHERE h.network = r.network AND h.celestial_id = r.id
+	), now()) FROM restored AS r
+	ON CONFLICT (id, network) DO UPDATE SET status = EXCLUDED.status, updated_at = now(), status_changed_at = now()
+	WHERE celestial.change_id = EXCLUDED.change_id AND celestial.status != EXCLUDED.status
+	RETURNING *`
+)
+
+// RollbackTo - reverts celestial ids of the state network to how they were after applying the change with passed id.
+// Celestial ids changed later are restored from history, history and failed changes after the change are removed
+// and state is rewound to the change id. Change id can't be less than the first change id in history of the network
+// because celestial ids indexed before history can't be restored.
+//
+// It returns states of every changed celestial id before and after rollback. Celestial ids which are absent in after
+// are removed.
+func (tx CelestialTransaction) RollbackTo(ctx context.Context, state *storage.CelestialState, changeId int64) (before, after []storage.Celestial, err error) {
+	if changeId < 0 || changeId > state.ChangeId {
+		return nil, nil, errors.Errorf("invalid rollback change id %d: state change id is %d", changeId, state.ChangeId)
+	}
+	if changeId == state.ChangeId {
+		return nil, nil, nil
+	}
+
+	var first sql.NullInt64
+	if err := tx.Tx().NewSelect().
+		Model((*storage.CelestialHistory)(nil)).
+		ColumnExpr("min(change_id)").
+		Where("network = ?", state.Network).
+		Scan(ctx, &first); err != nil {
+		return nil, nil, errors.Wrap(err, "first history change id")
+	}
+	if !first.Valid {
+		return nil, nil, errors.Errorf("invalid rollback change id %d: history of %s is empty", changeId, state.Network)
+	}
+	if changeId < first.Int64 {
+		return nil, nil, errors.Errorf("invalid rollback change id %d: history of %s starts from change id %d", changeId, state.Network, first.Int64)
+	}
+
+	if err := tx.Tx().NewDelete().
+		Model(&before).
+		Where("network = ?", state.Network).
+		Where("change_id > ?", changeId).
+		Returning("*").
+		Scan(ctx); err != nil {
+		return nil, nil, errors.Wrap(err, "delete celestials")
+	}
+
+	var revoked []storage.Celestial
+	if err := tx.Tx().NewRaw(revokePrimaryQuery, state.Network, changeId).Scan(ctx, &revoked); err != nil {
+		return nil, nil, errors.Wrap(err, "revoke primary statuses")
+	}
+	var changed []storage.Celestial
+	if err := tx.Tx().NewRaw(restoredStatusesQuery, state.Network, changeId).Scan(ctx, &changed); err != nil {
+		return nil, nil, errors.Wrap(err, "restored statuses")
+	}
+	var restored []storage.Celestial
+	if err := tx.Tx().NewRaw(restoreFromHistoryQuery, state.Network, changeId).Scan(ctx, &restored); err != nil {
+		return nil, nil, errors.Wrap(err, "restore celestials")
+	}
+
+	seen := make(map[string]struct{}, len(before)+len(revoked))
+	for i := range before {
+		seen[before[i].Id] = struct{}{}
+	}
+	for i := range revoked {
+		prev := revoked[i]
+		prev.Status = storage.StatusPRIMARY
+		before = append(before, prev)
+		seen[prev.Id] = struct{}{}
+	}
+	for i := range changed {
+		if _, ok := seen[changed[i].Id]; !ok {
+			before = append(before, changed[i])
+		}
+	}
+
+	restoredIds := make(map[string]struct{}, len(restored))
+	for i := range restored {
+		restoredIds[restored[i].Id] = struct{}{}
+	}
+	after = restored
+	for i := range revoked {
+		if _, ok := restoredIds[revoked[i].Id]; !ok {
+			after = append(after, revoked[i])
+		}
+	}
+
+	if _, err := tx.Tx().NewDelete().
+		Model((*storage.CelestialHistory)(nil)).
+		Where("network = ?", state.Network).
+		Where("change_id > ?", changeId).
+		Exec(ctx); err != nil {
+		return nil, nil, errors.Wrap(err, "delete history")
+	}
+	if _, err := tx.Tx().NewDelete().
+		Model((*storage.CelestialFailedChange)(nil)).
+		Where("network = ?", state.Network).
+		Where("change_id > ?", changeId).
+		Exec(ctx); err != nil {
+		return nil, nil, errors.Wrap(err, "delete failed changes")
+	}
+
+	if _, err := tx.Tx().NewUpdate().
+		Model(state).
+		Set("change_id = ?", changeId).
+		WherePK().
+		Exec(ctx); err != nil {
+		return nil, nil, errors.Wrap(err, "update state")
+	}
+	state.ChangeId = changeId
+	return before, after, nil
+}
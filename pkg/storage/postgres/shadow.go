@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"iter"
+
+	"github.com/celenium-io/celestial-module/pkg/storage"
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+)
+
+const (
+	// shadowCelestialTable - table which receives celestial ids during resync. It has the same structure as celestial table.
+	shadowCelestialTable = "celestial_shadow"
+	// shadowHistoryTable - table which receives history of replayed changes during resync. It has the same structure
+	// as celestial_history table.
+	shadowHistoryTable = "celestial_history_shadow"
+
+	// changedCelestialsQuery - rows of the first table which are absent in the second one or differ from it
+	changedCelestialsQuery = `SELECT c.* FROM ? AS c
+		WHERE c.network = ? AND NOT EXISTS (
+			SELECT 1 FROM ? AS o
+			WHERE o.network = c.network AND o.id = c.id AND o.address_id = c.address_id AND o.status = c.status
+				AND o.change_id = c.change_id AND o.image_url IS NOT DISTINCT FROM c.image_url
+		)
+		ORDER BY c.id`
+)
+
+func (tx CelestialTransaction) CreateShadow(ctx context.Context, network string) error {
+	if _, err := tx.Tx().ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS ? (LIKE ? INCLUDING ALL)`,
+		bun.Ident(shadowCelestialTable), bun.Ident(storage.Celestial{}.TableName()),
+	); err != nil {
+		return errors.Wrap(err, "create shadow table")
+	}
+	if _, err := tx.Tx().ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS ? (LIKE ? INCLUDING ALL)`,
+		bun.Ident(shadowHistoryTable), bun.Ident(storage.CelestialHistory{}.TableName()),
+	); err != nil {
+		return errors.Wrap(err, "create shadow history table")
+	}
+
+	if _, err := tx.Tx().NewDelete().
+		Model((*storage.CelestialHistory)(nil)).
+		ModelTableExpr("? AS celestial_history", bun.Ident(shadowHistoryTable)).
+		Where("network = ?", network).
+		Exec(ctx); err != nil {
+		return errors.Wrap(err, "clear shadow history table")
+	}
+
+	_, err := tx.Tx().NewDelete().
+		Model((*storage.Celestial)(nil)).
+		ModelTableExpr("? AS celestial", bun.Ident(shadowCelestialTable)).
+		Where("network = ?", network).
+		Exec(ctx)
+	return err
+}
+
+func (tx CelestialTransaction) SaveShadowCelestials(ctx context.Context, celestials iter.Seq[storage.Celestial]) error {
+	return tx.saveCelestials(ctx, shadowCelestialTable, celestials)
+}
+
+func (tx CelestialTransaction) SaveShadowHistory(ctx context.Context, history ...storage.CelestialHistory) error {
+	if len(history) == 0 {
+		return nil
+	}
+	_, err := tx.Tx().NewInsert().
+		Model(&history).
+		ModelTableExpr("? AS celestial_history", bun.Ident(shadowHistoryTable)).
+		Exec(ctx)
+	return err
+}
+
+func (tx CelestialTransaction) UpdateShadowStatusForAddress(ctx context.Context, network string, primaries map[uint64]string) ([]storage.Celestial, error) {
+	return tx.updateStatusForAddress(ctx, shadowCelestialTable, network, primaries)
+}
+
+// SwapShadow - replaces celestial ids and history of the network by rows of shadow tables. Creation time of celestial ids
+// is taken from replaced rows and status change time is kept if status was not changed during resync. History is rebuilt
+// from replayed changes, so indexing time of history rows is the time of replay.
+//
+// It returns live rows which are changed or removed by swap and shadow rows which are changed or added by swap.
+func (tx CelestialTransaction) SwapShadow(ctx context.Context, network string) (before, after []storage.Celestial, err error) {
+	live := bun.Ident(storage.Celestial{}.TableName())
+	shadow := bun.Ident(shadowCelestialTable)
+
+	if err := tx.Tx().NewRaw(changedCelestialsQuery, live, network, shadow).Scan(ctx, &before); err != nil {
+		return nil, nil, errors.Wrap(err, "changed live celestials")
+	}
+	if err := tx.Tx().NewRaw(changedCelestialsQuery, shadow, network, live).Scan(ctx, &after); err != nil {
+		return nil, nil, errors.Wrap(err, "changed shadow celestials")
+	}
+
+	if _, err := tx.Tx().ExecContext(ctx,
+		`UPDATE ? AS s SET created_at = c.created_at,
+			status_changed_at = CASE WHEN s.status = c.status THEN c.status_changed_at ELSE s.status_changed_at END
+		FROM ? AS c WHERE s.network = ? AND c.network = s.network AND c.id = s.id`,
+		shadow, live, network,
+	); err != nil {
+		return nil, nil, errors.Wrap(err, "copy timestamps")
+	}
+
+	if _, err := tx.Tx().NewDelete().
+		Model((*storage.Celestial)(nil)).
+		Where("network = ?", network).
+		Exec(ctx); err != nil {
+		return nil, nil, errors.Wrap(err, "delete celestials")
+	}
+
+	if _, err := tx.Tx().ExecContext(ctx,
+		`INSERT INTO ? (id, network, address_id, image_url, change_id, status, created_at, updated_at, status_changed_at)
+		SELECT id, network, address_id, image_url, change_id, status, created_at, updated_at, status_changed_at FROM ? WHERE network = ?`,
+		live, shadow, network,
+	); err != nil {
+		return nil, nil, errors.Wrap(err, "copy shadow celestials")
+	}
+
+	if _, err := tx.Tx().NewDelete().
+		Model((*storage.CelestialHistory)(nil)).
+		Where("network = ?", network).
+		Exec(ctx); err != nil {
+		return nil, nil, errors.Wrap(err, "delete history")
+	}
+
+	if _, err := tx.Tx().ExecContext(ctx,
+		`INSERT INTO ? (indexed_at, change_id, network, celestial_id, address_id, image_url, status)
+		SELECT indexed_at, change_id, network, celestial_id, address_id, image_url, status FROM ? WHERE network = ?`,
+		bun.Ident(storage.CelestialHistory{}.TableName()), bun.Ident(shadowHistoryTable), network,
+	); err != nil {
+		return nil, nil, errors.Wrap(err, "copy shadow history")
+	}
+
+	if _, err := tx.Tx().NewDelete().
+		Model((*storage.CelestialHistory)(nil)).
+		ModelTableExpr("? AS celestial_history", bun.Ident(shadowHistoryTable)).
+		Where("network = ?", network).
+		Exec(ctx); err != nil {
+		return nil, nil, errors.Wrap(err, "clear shadow history table")
+	}
+
+	if _, err := tx.Tx().NewDelete().
+		Model((*storage.Celestial)(nil)).
+		ModelTableExpr("? AS celestial", shadow).
+		Where("network = ?", network).
+		Exec(ctx); err != nil {
+		return nil, nil, errors.Wrap(err, "clear shadow table")
+	}
+	return before, after, nil
+}
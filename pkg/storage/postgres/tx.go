@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"iter"
+	"maps"
 	"slices"
 
 	"github.com/celenium-io/celestial-module/pkg/storage"
@@ -19,16 +20,70 @@ func BeginCelestialTransaction(ctx context.Context, tx sdk.Transactable) (Celest
 	return CelestialTransaction{t}, err
 }
 
+func (tx CelestialTransaction) CelestialsByIds(ctx context.Context, network string, ids iter.Seq[string]) (result []storage.Celestial, err error) {
+	err = tx.Tx().NewSelect().
+		Model(&result).
+		Where("network = ?", network).
+		Where("id IN (?)", bun.In(slices.Collect(ids))).
+		Scan(ctx)
+	return
+}
+
+// CelestialsAfter - returns celestial ids of network ordered by id which are greater than passed one
+func (tx CelestialTransaction) CelestialsAfter(ctx context.Context, network, afterId string, limit int) (result []storage.Celestial, err error) {
+	err = tx.Tx().NewSelect().
+		Model(&result).
+		Where("network = ?", network).
+		Where("id > ?", afterId).
+		OrderExpr("id asc").
+		Limit(limit).
+		Scan(ctx)
+	return
+}
+
+// saveCelestialsChunkSize - max count of rows in one upsert statement
+const saveCelestialsChunkSize = 1000
+
+// SaveCelestials - upserts celestial ids by multi-row statements. Celestial id is not updated if stored change id is greater.
+// If sequence contains the same celestial id several times, the one with the greatest change id is saved.
+// Timestamps are set by database: created_at is kept on update and status_changed_at is changed only with status.
 func (tx CelestialTransaction) SaveCelestials(ctx context.Context, celestials iter.Seq[storage.Celestial]) error {
+	return tx.saveCelestials(ctx, storage.Celestial{}.TableName(), celestials)
+}
+
+func (tx CelestialTransaction) saveCelestials(ctx context.Context, table string, celestials iter.Seq[storage.Celestial]) error {
+	type key struct {
+		id      string
+		network string
+	}
+
+	indices := make(map[key]int)
+	rows := make([]storage.Celestial, 0)
 	for cel := range celestials {
+		k := key{cel.Id, cel.Network}
+		if i, ok := indices[k]; ok {
+			if rows[i].ChangeId <= cel.ChangeId {
+				rows[i] = cel
+			}
+			continue
+		}
+		indices[k] = len(rows)
+		rows = append(rows, cel)
+	}
+
+	for chunk := range slices.Chunk(rows, saveCelestialsChunkSize) {
 		_, err := tx.Tx().NewInsert().
-			Model(&cel).
-			Column("id", "address_id", "image_url", "change_id", "status").
-			On("CONFLICT (id) DO UPDATE").
+			Model(&chunk).
+			ModelTableExpr("? AS celestial", bun.Ident(table)).
+			Column("id", "network", "address_id", "image_url", "change_id", "status").
+			On("CONFLICT (id, network) DO UPDATE").
 			Set("address_id = EXCLUDED.address_id").
 			Set("image_url = EXCLUDED.image_url").
 			Set("change_id = EXCLUDED.change_id").
 			Set("status = EXCLUDED.status").
+			Set("updated_at = EXCLUDED.updated_at").
+			Set("status_changed_at = CASE WHEN celestial.status = EXCLUDED.status THEN celestial.status_changed_at ELSE EXCLUDED.status_changed_at END").
+			Where("celestial.change_id <= EXCLUDED.change_id").
 			Exec(ctx)
 		if err != nil {
 			return err
@@ -37,21 +92,125 @@ func (tx CelestialTransaction) SaveCelestials(ctx context.Context, celestials it
 	return nil
 }
 
+func (tx CelestialTransaction) SaveHistory(ctx context.Context, history ...storage.CelestialHistory) error {
+	if len(history) == 0 {
+		return nil
+	}
+	_, err := tx.Tx().NewInsert().
+		Model(&history).
+		Exec(ctx)
+	return err
+}
+
 func (tx CelestialTransaction) UpdateState(ctx context.Context, state *storage.CelestialState) error {
-	_, err := tx.Tx().NewUpdate().
+	result, err := tx.Tx().NewUpdate().
 		Model(state).
 		Set("change_id = ?", state.ChangeId).
 		WherePK().
+		Where("change_id <= ?", state.ChangeId).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return storage.ErrStaleState
+	}
+	return nil
+}
+
+// DeleteState - removes state
+func (tx CelestialTransaction) DeleteState(ctx context.Context, state *storage.CelestialState) error {
+	_, err := tx.Tx().NewDelete().
+		Model(state).
+		WherePK().
 		Exec(ctx)
 	return err
 }
 
-func (tx CelestialTransaction) UpdateStatusForAddress(ctx context.Context, addressId iter.Seq[uint64]) error {
-	_, err := tx.Tx().NewUpdate().
+// UpdateStatusForAddress - revokes primary status of celestial ids connected to addresses from the map and returns revoked rows.
+// Map values are celestial ids which become primary for these addresses: they keep the status at the same address,
+// so status_changed_at of celestial id which stays primary is not changed. Celestial id which becomes primary
+// for other address is revoked, otherwise it would violate the unique index until it is moved by upsert.
+func (tx CelestialTransaction) UpdateStatusForAddress(ctx context.Context, network string, primaries map[uint64]string) ([]storage.Celestial, error) {
+	return tx.updateStatusForAddress(ctx, storage.Celestial{}.TableName(), network, primaries)
+}
+
+func (tx CelestialTransaction) updateStatusForAddress(ctx context.Context, table, network string, primaries map[uint64]string) (revoked []storage.Celestial, err error) {
+	if len(primaries) == 0 {
+		return nil, nil
+	}
+	kept := make([][]any, 0, len(primaries))
+	for addressId, id := range primaries {
+		kept = append(kept, []any{addressId, id})
+	}
+
+	err = tx.Tx().NewUpdate().
 		Model((*storage.Celestial)(nil)).
+		ModelTableExpr("? AS celestial", bun.Ident(table)).
 		Set("status = ?", storage.StatusVERIFIED).
-		Where("address_id IN ?", bun.Tuple(slices.Collect(addressId))).
+		Set("updated_at = now()").
+		Set("status_changed_at = now()").
+		Where("network = ?", network).
+		Where("address_id IN (?)", bun.In(slices.Collect(maps.Keys(primaries)))).
+		Where("(address_id, id) NOT IN (?)", bun.In(kept)).
 		Where("status = ?", storage.StatusPRIMARY).
+		Returning("*").
+		Scan(ctx, &revoked)
+	return
+}
+
+// FailedChanges - returns failed changes which are ready for the next processing attempt ordered by change id.
+// Received rows are locked until the end of transaction and skipped by concurrent transactions.
+func (tx CelestialTransaction) FailedChanges(ctx context.Context, network string, limit int) (result []storage.CelestialFailedChange, err error) {
+	err = tx.Tx().NewSelect().
+		Model(&result).
+		Where("network = ?", network).
+		Where("next_retry_at <= now()").
+		OrderExpr("change_id asc").
+		Limit(limit).
+		For("UPDATE SKIP LOCKED").
+		Scan(ctx)
+	return
+}
+
+func (tx CelestialTransaction) SaveFailedChanges(ctx context.Context, changes ...storage.CelestialFailedChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	_, err := tx.Tx().NewInsert().
+		Model(&changes).
+		On("CONFLICT (change_id, network) DO UPDATE").
+		Set("error = EXCLUDED.error").
+		Set("attempts = EXCLUDED.attempts").
+		Set("next_retry_at = EXCLUDED.next_retry_at").
+		Exec(ctx)
+	return err
+}
+
+// SaveNewFailedChanges - saves failed changes which are not stored yet. Stored ones keep their attempts and retry time.
+func (tx CelestialTransaction) SaveNewFailedChanges(ctx context.Context, changes ...storage.CelestialFailedChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	_, err := tx.Tx().NewInsert().
+		Model(&changes).
+		On("CONFLICT (change_id, network) DO NOTHING").
+		Exec(ctx)
+	return err
+}
+
+func (tx CelestialTransaction) DeleteFailedChanges(ctx context.Context, network string, changeIds ...int64) error {
+	if len(changeIds) == 0 {
+		return nil
+	}
+	_, err := tx.Tx().NewDelete().
+		Model((*storage.CelestialFailedChange)(nil)).
+		Where("network = ?", network).
+		Where("change_id IN (?)", bun.In(changeIds)).
 		Exec(ctx)
 	return err
 }
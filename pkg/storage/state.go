@@ -4,20 +4,25 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/pkg/errors"
 	"github.com/uptrace/bun"
 )
 
+// ErrStaleState - returned by UpdateState if stored change id is greater than the new one
+var ErrStaleState = errors.New("stored state has greater change id")
+
 //go:generate mockgen -source=$GOFILE -destination=mock/$GOFILE -package=mock -typed
 type ICelestialState interface {
-	ByName(ctx context.Context, name string) (CelestialState, error)
+	ByName(ctx context.Context, name, network string) (CelestialState, error)
 	Save(ctx context.Context, state *CelestialState) error
 }
 
 type CelestialState struct {
 	bun.BaseModel `bun:"celestial_state" comment:"Table with celestial ids."`
 
-	Name     string `bun:"name,pk,notnull" comment:"Celestial id indexer name"`
-	ChangeId int64  `bun:"change_id"       comment:"Id of the last change of celestial id"`
+	Name     string `bun:"name,pk,notnull"    comment:"Celestial id indexer name"`
+	Network  string `bun:"network,pk,notnull" comment:"Indexed network (chain id)"`
+	ChangeId int64  `bun:"change_id"          comment:"Id of the last change of celestial id"`
 }
 
 func (CelestialState) TableName() string {
@@ -25,5 +30,5 @@ func (CelestialState) TableName() string {
 }
 
 func (cid CelestialState) String() string {
-	return fmt.Sprintf("%s %d", cid.Name, cid.ChangeId)
+	return fmt.Sprintf("%s %s %d", cid.Name, cid.Network, cid.ChangeId)
 }
@@ -9,9 +9,35 @@ import (
 
 //go:generate mockgen -source=$GOFILE -destination=mock/$GOFILE -package=mock -typed
 type CelestialTransaction interface {
+	CelestialsByIds(ctx context.Context, network string, ids iter.Seq[string]) ([]Celestial, error)
+	// CelestialsAfter - returns celestial ids of network ordered by id which are greater than passed one
+	CelestialsAfter(ctx context.Context, network, afterId string, limit int) ([]Celestial, error)
 	SaveCelestials(ctx context.Context, celestials iter.Seq[Celestial]) error
+	SaveHistory(ctx context.Context, history ...CelestialHistory) error
+	// UpdateState - saves change id of state. It returns ErrStaleState and does not update state if it would move change id backwards.
 	UpdateState(ctx context.Context, state *CelestialState) error
-	UpdateStatusForAddress(ctx context.Context, addressId ...iter.Seq[uint64]) error
+	DeleteState(ctx context.Context, state *CelestialState) error
+	// UpdateStatusForAddress - revokes primary status of celestial ids connected to addresses from the map
+	// except celestial id which is the map value for the same address. It returns revoked rows.
+	UpdateStatusForAddress(ctx context.Context, network string, primaries map[uint64]string) ([]Celestial, error)
+	// RollbackTo - restores celestial ids of the state network from history as they were after applying the change
+	// with passed id and rewinds state to it. Change id can't be less than the first change id in history.
+	// It returns states of changed celestial ids before and after rollback.
+	RollbackTo(ctx context.Context, state *CelestialState, changeId int64) (before, after []Celestial, err error)
+
+	// CreateShadow - creates shadow tables of celestial ids and history if they do not exist and removes rows of the network from them
+	CreateShadow(ctx context.Context, network string) error
+	SaveShadowCelestials(ctx context.Context, celestials iter.Seq[Celestial]) error
+	SaveShadowHistory(ctx context.Context, history ...CelestialHistory) error
+	UpdateShadowStatusForAddress(ctx context.Context, network string, primaries map[uint64]string) ([]Celestial, error)
+	// SwapShadow - replaces celestial ids and history of the network by rows of shadow tables and removes them from shadow tables.
+	// It returns live rows changed or removed by swap and shadow rows changed or added by swap.
+	SwapShadow(ctx context.Context, network string) (before, after []Celestial, err error)
+	FailedChanges(ctx context.Context, network string, limit int) ([]CelestialFailedChange, error)
+	SaveFailedChanges(ctx context.Context, changes ...CelestialFailedChange) error
+	// SaveNewFailedChanges - saves failed changes which are not stored yet without resetting retry state of stored ones
+	SaveNewFailedChanges(ctx context.Context, changes ...CelestialFailedChange) error
+	DeleteFailedChanges(ctx context.Context, network string, changeIds ...int64) error
 
 	sdk.Transaction
 }